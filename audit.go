@@ -0,0 +1,78 @@
+package justbe
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// auditDocument is the JSON form of a scan written by --report-audit. It
+// holds the matches like scanDocument, along with what happened to every
+// path the run was given.
+type auditDocument struct {
+	Matches        []MatchedLine `json:"matches"`
+	ProcessedFiles []string      `json:"processed_files"`
+	SkippedFiles   []skippedFile `json:"skipped_files"`
+	Errors         []auditError  `json:"errors"`
+}
+
+type skippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// auditError is a file error that did not stop the run.
+type auditError struct {
+	Path  string `json:"path"`
+	Op    string `json:"op"`
+	Error string `json:"error"`
+}
+
+// skippedFiles and auditErrors collect the audit entries of the current
+// run. Errors stop a run unless --skip-unreadable is given, so only then
+// is auditErrors filled.
+var (
+	skippedFiles []skippedFile
+	auditErrors  []auditError
+)
+
+// recordSkipped notes each path of before missing from after as skipped
+// for reason, so a filter need not know it is being audited.
+func recordSkipped(before []string, after []string, reason string) {
+	kept := make(map[string]bool, len(after))
+	for _, path := range after {
+		kept[path] = true
+	}
+
+	for _, path := range before {
+		if !kept[path] {
+			skippedFiles = append(skippedFiles, skippedFile{Path: path, Reason: reason})
+		}
+	}
+}
+
+func encodeAuditDocument(matches []MatchedLine, paths []string) ([]byte, error) {
+	doc := auditDocument{
+		Matches:        displayMatches(matches),
+		ProcessedFiles: displayPaths(paths),
+		SkippedFiles:   make([]skippedFile, 0, len(skippedFiles)),
+		Errors:         make([]auditError, 0, len(auditErrors)),
+	}
+	for _, skipped := range skippedFiles {
+		skipped.Path = formatPath(skipped.Path)
+		doc.SkippedFiles = append(doc.SkippedFiles, skipped)
+	}
+	// the error text names the file too, so it is rewritten to match
+	for _, auditErr := range auditErrors {
+		auditErr.Error = strings.ReplaceAll(auditErr.Error, auditErr.Path, formatPath(auditErr.Path))
+		auditErr.Path = formatPath(auditErr.Path)
+		doc.Errors = append(doc.Errors, auditErr)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding audit: %v", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,174 @@
+package justbe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	ModTime  time.Time     `json:"mod_time"`
+	Size     int64         `json:"size"`
+	Settings string        `json:"settings"`
+	Matches  []MatchedLine `json:"matches"`
+	SHA256   string        `json:"sha256,omitempty"`
+}
+
+// cacheVersion is bumped whenever MatchedLine gains a field, so entries
+// stored without it are rescanned rather than reported with a zero value.
+const cacheVersion = 4
+
+// cacheSettings describes the options that affect which matches a scan
+// finds. Entries stored under different settings are not reused.
+func cacheSettings() string {
+	return fmt.Sprintf("v%d %+v", cacheVersion, scanOptions())
+}
+
+type scanCache struct {
+	Entries map[string]cacheEntry `json:"entries"`
+
+	// mu guards Entries while files are scanned concurrently.
+	mu sync.Mutex
+}
+
+// openCache loads the cache stored at path. An empty path disables caching
+// and returns a nil cache. A missing or unreadable cache file starts over
+// with an empty cache rather than failing the run.
+func openCache(path string) (*scanCache, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	cache := &scanCache{Entries: make(map[string]cacheEntry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache %s: %v", path, err)
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil {
+		slog.Warn("ignoring invalid cache", "path", path, "error", err)
+		return &scanCache{Entries: make(map[string]cacheEntry)}, nil
+	}
+
+	if cache.Entries == nil {
+		cache.Entries = make(map[string]cacheEntry)
+	}
+
+	return cache, nil
+}
+
+func (c *scanCache) lookup(path string, info fs.FileInfo) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.Entries[path]
+	if !found {
+		return cacheEntry{}, false
+	}
+
+	if entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) || entry.Settings != cacheSettings() {
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (c *scanCache) store(path string, info fs.FileInfo, matches []MatchedLine, sha256 string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Entries[path] = cacheEntry{
+		ModTime:  info.ModTime(),
+		Size:     info.Size(),
+		Settings: cacheSettings(),
+		Matches:  matches,
+		SHA256:   sha256,
+	}
+}
+
+func (c *scanCache) save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("error encoding cache: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing cache %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// scanFile appends the matches for path, taking them from cache when the
+// file's size and modification time are unchanged since they were stored.
+// At most limit matches are appended unless limit is 0.
+func scanFile(path string, cache *scanCache, limit int, matches *[]MatchedLine) error {
+	if cache == nil || path == stdinPath {
+		return processFile(path, limit, matches)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error reading file info %s: %v", path, err)
+	}
+
+	if entry, found := cache.lookup(path, info); found {
+		slog.Debug("using cached matches", "path", path)
+		if err := cachedHash(path, entry); err != nil {
+			return err
+		}
+
+		cached := entry.Matches
+		if limit > 0 && len(cached) > limit {
+			cached = cached[:limit]
+		}
+		liveCount.add(len(cached))
+		*matches = append(*matches, cached...)
+		return nil
+	}
+
+	var fileMatches []MatchedLine
+	if err := processFile(path, limit, &fileMatches); err != nil {
+		return err
+	}
+
+	// a scan that reached the limit may have stopped early, so caching it
+	// would hide the rest of the file from later runs
+	if limit == 0 || len(fileMatches) < limit {
+		cache.store(path, info, fileMatches, recordedHash(path))
+	}
+	*matches = append(*matches, fileMatches...)
+
+	return nil
+}
+
+// cachedHash records the hash of a file whose matches came from entry. An
+// entry stored by a run without --report-hashes has no hash, so the file
+// is read just for hashing.
+func cachedHash(path string, entry cacheEntry) error {
+	if fileHashes == nil {
+		return nil
+	}
+
+	if entry.SHA256 != "" {
+		recordHash(path, entry.SHA256)
+		return nil
+	}
+
+	sum, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+	recordHash(path, sum)
+
+	return nil
+}
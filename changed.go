@@ -0,0 +1,75 @@
+package justbe
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// runGit runs git in the current directory and returns its trimmed
+// stdout. A failure includes what git printed on stderr, which already
+// explains problems such as an unknown ref.
+func runGit(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), message)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// resolvedPath returns the absolute path with symlinks resolved, so paths
+// reported by git compare equal to the ones given on the command line.
+func resolvedPath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	if realPath, err := filepath.EvalSymlinks(absPath); err == nil {
+		return realPath
+	}
+
+	return absPath
+}
+
+// filterChangedSince keeps the paths that git reports as changed between
+// ref and the working tree of the repository holding the current
+// directory.
+func filterChangedSince(paths []string, ref string) ([]string, error) {
+	root, err := runGit("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("--changed-since needs the current directory to be in a git repository: %v", err)
+	}
+
+	names, err := runGit("diff", "--name-only", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("error listing files changed since %s: %v", ref, err)
+	}
+
+	changed := make(map[string]bool)
+	for _, name := range strings.Split(names, "\n") {
+		if name != "" {
+			changed[resolvedPath(filepath.Join(root, name))] = true
+		}
+	}
+
+	var kept []string
+	for _, path := range paths {
+		if changed[resolvedPath(path)] {
+			kept = append(kept, path)
+		}
+	}
+
+	return kept, nil
+}
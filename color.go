@@ -0,0 +1,48 @@
+package justbe
+
+import (
+	"os"
+)
+
+var colorEnabled bool
+
+var ansiColors = map[string]string{
+	"name":  "\x1b[36m",
+	"count": "\x1b[33m",
+	"path":  "\x1b[32m",
+
+	"keyword": "\x1b[35m",
+}
+
+const ansiReset = "\x1b[0m"
+
+// setupColor decides whether reports are colorized. In auto mode color is
+// only used when stdout is a terminal, so piped output stays plain.
+func setupColor() {
+	switch opts.Color {
+	case "always":
+		colorEnabled = true
+	case "never":
+		colorEnabled = false
+	default:
+		colorEnabled = isTerminal(os.Stdout)
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint wraps s in the ANSI color for kind when color is enabled. Padding
+// must be applied before painting since escape codes count toward widths.
+func paint(kind string, s string) string {
+	code, found := ansiColors[kind]
+	if !colorEnabled || !found {
+		return s
+	}
+	return code + s + ansiReset
+}
@@ -0,0 +1,178 @@
+package justbe
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigPath returns ~/.config/justbe/config.yaml, or "" when the
+// home directory is unknown.
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "justbe", "config.yaml")
+}
+
+// loadConfig reads the YAML mapping at path, keyed by long flag name. A
+// missing file is only an error when it was named with --config.
+func loadConfig(path string, required bool) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading config %s: %v", path, err)
+	}
+
+	var config map[string]any
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// applyConfig sets the options named in the config file to its values,
+// except for options given on the command line or in their environment
+// variable, which take precedence. The reports key lists the commands
+// whose reports are selected when none is given.
+func applyConfig(parser *flags.Parser) error {
+	path, required := opts.Config, true
+	if path == "" {
+		path, required = defaultConfigPath(), false
+	}
+	if path == "" {
+		return nil
+	}
+
+	config, err := loadConfig(path, required)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values, err := configValues(config[key])
+		if err != nil {
+			return fmt.Errorf("invalid config %s: %s: %v", path, key, err)
+		}
+
+		if key == "reports" {
+			if err := applyConfigReports(values); err != nil {
+				return fmt.Errorf("invalid config %s: %s: %v", path, key, err)
+			}
+			continue
+		}
+
+		option := findConfigOption(parser, key)
+		if option == nil || key == "config" {
+			return fmt.Errorf("invalid config %s: unknown option %s", path, key)
+		}
+		if !configurable(option) {
+			continue
+		}
+
+		// a slice option still holds its default, which the config values
+		// replace rather than add to
+		field := reflect.ValueOf(&opts).Elem().FieldByName(option.Field().Name)
+		field.Set(reflect.Zero(field.Type()))
+
+		for i := range values {
+			if err := option.Set(&values[i]); err != nil {
+				return fmt.Errorf("invalid config %s: %s: %v", path, key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// findConfigOption returns the option named longName, whether it belongs
+// to the root or to one of the report commands, since the config file
+// applies whichever command is given.
+func findConfigOption(parser *flags.Parser, longName string) *flags.Option {
+	if option := parser.FindOptionByLongName(longName); option != nil {
+		return option
+	}
+	for _, command := range parser.Commands() {
+		if option := command.FindOptionByLongName(longName); option != nil {
+			return option
+		}
+	}
+	return nil
+}
+
+// configurable reports whether option still has its default value, that
+// is it was neither given on the command line nor in its environment
+// variable.
+func configurable(option *flags.Option) bool {
+	if option.IsSet() && !option.IsSetDefault() {
+		return false
+	}
+	if envKey := option.EnvKeyWithNamespace(); envKey != "" {
+		if _, found := os.LookupEnv(envKey); found {
+			return false
+		}
+	}
+	return true
+}
+
+// applyConfigReports checks the report names in values and keeps them for
+// applyCommand, which selects them when no report was given.
+func applyConfigReports(values []string) error {
+	for _, value := range values {
+		found := false
+		for _, command := range reportCommands {
+			found = found || command.name == value
+		}
+		if !found {
+			return fmt.Errorf("unknown report %q, expected matches, stats or names", value)
+		}
+	}
+
+	opts.configReports = values
+	return nil
+}
+
+// configValues turns a config value, a scalar or a list of scalars, into
+// the strings the option would be given on the command line.
+func configValues(value any) ([]string, error) {
+	items, isList := value.([]any)
+	if !isList {
+		items = []any{value}
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		switch item := item.(type) {
+		case string:
+			values = append(values, item)
+		case bool:
+			values = append(values, strconv.FormatBool(item))
+		case int:
+			values = append(values, strconv.Itoa(item))
+		case float64:
+			values = append(values, strconv.FormatFloat(item, 'f', -1, 64))
+		default:
+			return nil, fmt.Errorf("unsupported value %v, expected a string, number, boolean or a list of them", item)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,53 @@
+package justbe
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// genReportCSV renders the matches as CSV with a header row, or when the
+// name counts report is selected, the name counts with their places on
+// separate lines of one quoted field. A CSV file holds a single table, so
+// selecting both reports is an error.
+func genReportCSV(matches []MatchedLine) (string, error) {
+	if opts.ReportNameCounts && opts.ReportMatches {
+		return "", fmt.Errorf("--format csv writes one table, select either the matches or the names report")
+	}
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+
+	if opts.ReportNameCounts {
+		names, _ := duplicateNameInfos(matches)
+
+		records := [][]string{{"Name", "Count", "Places"}}
+		for _, info := range names {
+			records = append(records, []string{info.Name, strconv.Itoa(info.Count), strings.Join(info.Places, "\n")})
+		}
+		if err := w.WriteAll(records); err != nil {
+			return "", fmt.Errorf("error writing csv: %v", err)
+		}
+		return b.String(), nil
+	}
+
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatches(sortedMatches)
+
+	records := [][]string{{"FilePath", "LineNumber", "Name", "IndentLevel"}}
+	for _, match := range sortedMatches {
+		records = append(records, []string{
+			formatPath(match.FilePath),
+			strconv.Itoa(match.LineNumber),
+			match.Name,
+			strconv.Itoa(match.IndentLevel),
+		})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return "", fmt.Errorf("error writing csv: %v", err)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,162 @@
+package justbe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+type diffName struct {
+	Name   string
+	Places []string
+}
+
+type diffMove struct {
+	Name     string
+	FilePath string
+	From     []int
+	To       []int
+}
+
+// groupMatchesByName indexes matches by name key, then by file, to
+// the line numbers the name occupies in that file.
+func groupMatchesByName(matches []MatchedLine) (map[string]map[string][]int, map[string]string) {
+	lines := make(map[string]map[string][]int)
+	names := make(map[string]string)
+
+	for _, match := range matches {
+		key := nameKey(match.Name)
+		if _, found := lines[key]; !found {
+			lines[key] = make(map[string][]int)
+			names[key] = match.Name
+		}
+		lines[key][match.FilePath] = append(lines[key][match.FilePath], match.LineNumber)
+	}
+
+	for _, files := range lines {
+		for _, numbers := range files {
+			sort.Ints(numbers)
+		}
+	}
+
+	return lines, names
+}
+
+func diffPlaces(files map[string][]int) []string {
+	var places []string
+	for path, numbers := range files {
+		for _, number := range numbers {
+			places = append(places, fmt.Sprintf("%s:%d", formatPath(path), number))
+		}
+	}
+	sort.Strings(places)
+	return places
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func genReportDiff(baseline, current []MatchedLine) (string, error) {
+	baselineLines, baselineNames := groupMatchesByName(baseline)
+	currentLines, currentNames := groupMatchesByName(current)
+
+	var added, removed []diffName
+	var moved []diffMove
+
+	for key, files := range currentLines {
+		oldFiles, found := baselineLines[key]
+		if !found {
+			added = append(added, diffName{Name: currentNames[key], Places: diffPlaces(files)})
+			continue
+		}
+
+		for path, numbers := range files {
+			oldNumbers, found := oldFiles[path]
+			if found && !equalInts(oldNumbers, numbers) {
+				moved = append(moved, diffMove{
+					Name:     currentNames[key],
+					FilePath: path,
+					From:     oldNumbers,
+					To:       numbers,
+				})
+			}
+		}
+	}
+
+	for key, files := range baselineLines {
+		if _, found := currentLines[key]; !found {
+			removed = append(removed, diffName{Name: baselineNames[key], Places: diffPlaces(files)})
+		}
+	}
+
+	byName := func(names []diffName) {
+		sort.Slice(names, func(i, j int) bool {
+			return strings.ToLower(names[i].Name) < strings.ToLower(names[j].Name)
+		})
+	}
+	byName(added)
+	byName(removed)
+
+	sort.Slice(moved, func(i, j int) bool {
+		if !strings.EqualFold(moved[i].Name, moved[j].Name) {
+			return strings.ToLower(moved[i].Name) < strings.ToLower(moved[j].Name)
+		}
+		return moved[i].FilePath < moved[j].FilePath
+	})
+
+	const diffTemplate = `
+Added names, total: {{ formatNumWithCommas (len .Added) }}
+{{- range .Added }}
++ {{ .Name }}
+{{- range .Places }}
+    {{ . }}
+{{- end }}
+{{- end }}
+
+Removed names, total: {{ formatNumWithCommas (len .Removed) }}
+{{- range .Removed }}
+- {{ .Name }}
+{{- range .Places }}
+    {{ . }}
+{{- end }}
+{{- end }}
+
+Moved lines, total: {{ formatNumWithCommas (len .Moved) }}
+{{- range .Moved }}
+~ {{ .Name }} {{ formatPath .FilePath }}: {{ .From }} -> {{ .To }}
+{{- end }}
+`
+
+	tmpl, err := template.New("diff").Funcs(funcMap).Parse(diffTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	diffData := struct {
+		Added   []diffName
+		Removed []diffName
+		Moved   []diffMove
+	}{
+		Added:   added,
+		Removed: removed,
+		Moved:   moved,
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, diffData)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,232 @@
+package justbe
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// formatPath renders a file path for display in reports. The path stored
+// on MatchedLine is never modified.
+func formatPath(path string) string {
+	if path == stdinPath {
+		return path
+	}
+
+	if opts.Anonymize {
+		return anonymizePath(path)
+	}
+
+	switch opts.PathStyle {
+	case "rel":
+		return relativePath(path)
+	case "both":
+		return fmt.Sprintf("%s (%s)", relativePath(path), absolutePath(path))
+	}
+
+	if opts.GitRelative {
+		return gitRelativePath(path)
+	}
+
+	return path
+}
+
+// displayMatches returns a copy of matches with each FilePath rendered by
+// formatPath, for documents that carry the matches themselves.
+func displayMatches(matches []MatchedLine) []MatchedLine {
+	displayed := make([]MatchedLine, len(matches))
+	for i, match := range matches {
+		match.FilePath = formatPath(match.FilePath)
+		displayed[i] = match
+	}
+	return displayed
+}
+
+// displayPaths returns a copy of paths rendered by formatPath.
+func displayPaths(paths []string) []string {
+	displayed := make([]string, len(paths))
+	for i, path := range paths {
+		displayed[i] = formatPath(path)
+	}
+	return displayed
+}
+
+// relativePath renders path relative to its git repository root with
+// --git-relative, otherwise relative to the current directory.
+func relativePath(path string) string {
+	if opts.GitRelative {
+		return gitRelativePath(path)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	relPath, err := filepath.Rel(cwd, absolutePath(path))
+	if err != nil {
+		return path
+	}
+
+	return relPath
+}
+
+func absolutePath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return absPath
+}
+
+var gitRoots = make(map[string]string)
+
+// findGitRoot returns the nearest directory at or above dir that contains
+// a .git entry, or "" when dir is not inside a git repository. Results are
+// remembered per directory since many files usually share one.
+func findGitRoot(dir string) string {
+	if root, found := gitRoots[dir]; found {
+		return root
+	}
+
+	root := ""
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = findGitRoot(parent)
+	}
+
+	gitRoots[dir] = root
+	return root
+}
+
+// gitRelativePath renders path relative to its git repository root,
+// falling back to the absolute path for files outside a repository.
+func gitRelativePath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	root := findGitRoot(filepath.Dir(absPath))
+	if root == "" {
+		return absPath
+	}
+
+	relPath, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return absPath
+	}
+
+	return relPath
+}
+
+// formatName renders a match name for display in columnar reports. When
+// --max-name-width is set, longer names are cut with an ellipsis and
+// shorter ones are padded so the columns that follow stay aligned.
+func formatName(name string) string {
+	return fitWidth(name, opts.MaxNameWidth)
+}
+
+// fitWidth pads s with spaces to width runes or cuts it with an ellipsis
+// when it is longer. A width of 0 or less returns s unchanged.
+func fitWidth(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+
+	runes := []rune(s)
+	if len(runes) > width {
+		if width == 1 {
+			return "…"
+		}
+		return string(runes[:width-1]) + "…"
+	}
+
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+// alignRight left pads s with spaces to width runes.
+func alignRight(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return strings.Repeat(" ", width-n) + s
+	}
+	return s
+}
+
+// anonymizePath maps a path to a short identifier that is stable across
+// runs, so the same file always gets the same id. Eight bytes of the hash
+// keep two files of even a large tree from sharing an id.
+func anonymizePath(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return fmt.Sprintf("file-%x", sum[:8])
+}
+
+func genReportLegend(paths []string) (string, error) {
+	type LegendEntry struct {
+		ID   string
+		Path string
+	}
+
+	seen := make(map[string]bool)
+	entries := make([]LegendEntry, 0, len(paths))
+
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		entries = append(entries, LegendEntry{ID: anonymizePath(path), Path: path})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+
+	legendTemplate := `
+Legend:
+{{range .}}{{printf "%s: %s\n" .ID .Path}}{{end}}`
+
+	tmpl, err := template.New("legend").Funcs(funcMap).Parse(legendTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, entries)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+// keywordLabels parses --keyword-label values of the form keyword=label
+// into a map keyed by the lowercased keyword.
+func keywordLabels(keywords []string, specs []string) (map[string]string, error) {
+	labels := make(map[string]string, len(specs))
+
+	for _, spec := range specs {
+		keyword, label, found := strings.Cut(spec, "=")
+		if !found || keyword == "" {
+			return nil, fmt.Errorf("invalid keyword label %q, expected keyword=label", spec)
+		}
+
+		known := false
+		for _, configured := range keywords {
+			known = known || strings.EqualFold(configured, keyword)
+		}
+		if !known {
+			return nil, fmt.Errorf("invalid keyword label %q, %s is not a --keyword", spec, keyword)
+		}
+
+		labels[strings.ToLower(keyword)] = label
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,48 @@
+package justbe
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotTextFile is reported by CanProcessFiles for a file whose detected
+// type is not plain UTF-8 text.
+var ErrNotTextFile = errors.New("not a text file")
+
+// ErrFileTooLarge is reported for a file larger than --max-file-size.
+var ErrFileTooLarge = errors.New("larger than the size limit")
+
+// ErrNoMatches is reported by --require-match when files have no matches.
+var ErrNoMatches = errors.New("have no matches")
+
+// ErrDuplicateNames is reported by --fail-on-duplicates when names are
+// found too often.
+var ErrDuplicateNames = errors.New("duplicate names")
+
+// FileError records a failure to check or read one file. Err is the
+// underlying cause, so errors.Is matches ErrNotTextFile as well as
+// fs.ErrNotExist and fs.ErrPermission from opening the file.
+type FileError struct {
+	// Op is the step that failed: "detect", "check", "size", "open" or
+	// "read".
+	Op   string
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	switch e.Op {
+	case "detect":
+		return fmt.Sprintf("error detecting mimetype of file %s: %v", e.Path, e.Err)
+	case "check", "size":
+		return fmt.Sprintf("file %s is %v", e.Path, e.Err)
+	case "open":
+		return fmt.Sprintf("error opening file %s: %v", e.Path, e.Err)
+	default:
+		return fmt.Sprintf("error reading file %s: %v", e.Path, e.Err)
+	}
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
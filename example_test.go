@@ -0,0 +1,31 @@
+package justbe_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/taylormonacelli/justbe"
+)
+
+func ExampleScanner() {
+	notes := "* Standup tidbits\nbody\n** Retro notes\n* Plain heading\n"
+
+	scanner := justbe.NewScanner(
+		justbe.WithReader("notes.org", strings.NewReader(notes)),
+		justbe.WithKeywords("tidbits", "notes"),
+	)
+
+	matches, err := scanner.Scan(context.Background())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, match := range matches {
+		fmt.Printf("%s:%d %s [%s]\n", match.FilePath, match.LineNumber, match.Name, match.Keyword)
+	}
+	// Output:
+	// notes.org:1 Standup [tidbits]
+	// notes.org:3 Retro [notes]
+}
@@ -0,0 +1,112 @@
+package justbe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+type ExecResult struct {
+	FilePath string
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Failed   bool
+}
+
+// runExec pipes matches to command as one JSON object per line and captures
+// what the command prints. The command is run by sh, so it is quoted as in
+// a shell, and gets path as $1. A command that exits nonzero is not an
+// error here; its exit code and stderr are recorded on the result instead.
+func runExec(command string, path string, matches []MatchedLine) (ExecResult, error) {
+	if strings.TrimSpace(command) == "" {
+		return ExecResult{}, fmt.Errorf("empty exec command")
+	}
+
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, match := range matches {
+		if err := encoder.Encode(match); err != nil {
+			return ExecResult{}, fmt.Errorf("error encoding match: %v", err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", command, "sh", path)
+	cmd.Stdin = &input
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	result := ExecResult{FilePath: path}
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.Failed = true
+		result.ExitCode = exitErr.ExitCode()
+		slog.Warn("exec command failed", "path", path, "exit_code", result.ExitCode)
+	case err != nil:
+		return ExecResult{}, fmt.Errorf("error running %s: %v", command, err)
+	}
+
+	result.Stdout = strings.TrimRight(stdout.String(), "\n")
+	result.Stderr = strings.TrimRight(stderr.String(), "\n")
+
+	return result, nil
+}
+
+func genReportExec(command string, matches []MatchedLine) (string, error) {
+	var paths []string
+	byFile := make(map[string][]MatchedLine)
+
+	for _, match := range matches {
+		if _, found := byFile[match.FilePath]; !found {
+			paths = append(paths, match.FilePath)
+		}
+		byFile[match.FilePath] = append(byFile[match.FilePath], match)
+	}
+
+	results := make([]ExecResult, 0, len(paths))
+	for _, path := range paths {
+		result, err := runExec(command, path, byFile[path])
+		if err != nil {
+			return "", err
+		}
+		results = append(results, result)
+	}
+
+	const execTemplate = `
+Exec output:
+{{- range . }}
+{{ formatPath .FilePath }}:
+{{- if .Stdout }}
+{{ .Stdout }}
+{{- end }}
+{{- if .Failed }}
+command failed with exit code {{ .ExitCode }}
+{{- if .Stderr }}
+{{ .Stderr }}
+{{- end }}
+{{- end }}
+{{ end -}}
+`
+
+	tmpl, err := template.New("exec").Funcs(funcMap).Parse(execTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, results)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,23 @@
+package justbe
+
+import "testing"
+
+func TestRunExec(t *testing.T) {
+	matches := []MatchedLine{{FilePath: "my notes.org", LineNumber: 1, Name: "A"}, {FilePath: "my notes.org", LineNumber: 2, Name: "B"}}
+
+	result, err := runExec(`printf '%s|%s|' "quoted arg" "$1"; wc -l | tr -d ' '`, "my notes.org", matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "quoted arg|my notes.org|2"; result.Stdout != want || result.Failed {
+		t.Errorf("result = %+v, want stdout %q", result, want)
+	}
+
+	result, err = runExec("echo oops >&2; exit 3", "my notes.org", matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Failed || result.ExitCode != 3 || result.Stderr != "oops" {
+		t.Errorf("result = %+v, want exit code 3 and stderr oops", result)
+	}
+}
@@ -0,0 +1,131 @@
+package justbe
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// fileInfo is what was detected about an input file, for diagnosing a
+// file that scans oddly.
+type fileInfo struct {
+	Path       string
+	Mimetype   string
+	LineEnding string
+	Size       uint64
+}
+
+// detectLineEnding names the line endings used in data: LF, CRLF, CR,
+// mixed when there is more than one kind, or none without line breaks.
+// Unless atEOF says data is the whole file, a CR in the last byte is not
+// counted since its LF may be past the end.
+func detectLineEnding(data []byte, atEOF bool) string {
+	var lf, crlf, cr int
+	for i := 0; i < len(data); i++ {
+		switch {
+		case data[i] == '\n':
+			lf++
+		case data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n':
+			crlf++
+			i++
+		case data[i] == '\r' && (i+1 < len(data) || atEOF):
+			cr++
+		}
+	}
+
+	kinds := 0
+	ending := "none"
+	for _, kind := range []struct {
+		name  string
+		count int
+	}{{"LF", lf}, {"CRLF", crlf}, {"CR", cr}} {
+		if kind.count > 0 {
+			kinds++
+			ending = kind.name
+		}
+	}
+	if kinds > 1 {
+		return "mixed"
+	}
+
+	return ending
+}
+
+// collectFileInfo sniffs the line endings of path from its first
+// lineEndingSniffSize bytes. Its mimetype is the one CanProcessFiles
+// detected, or for a file assumed to be text, detected from those same
+// bytes, which cover what the detection reads, so the file is read once.
+func collectFileInfo(path string) (fileInfo, error) {
+	if path == stdinPath {
+		n := min(len(stdinContent), lineEndingSniffSize)
+		return fileInfo{
+			Path:       path,
+			Mimetype:   mimetype.Detect(stdinContent).String(),
+			LineEnding: detectLineEnding(stdinContent[:n], n == len(stdinContent)),
+			Size:       uint64(len(stdinContent)),
+		}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fileInfo{}, &FileError{Op: "open", Path: path, Err: err}
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return fileInfo{}, &FileError{Op: "open", Path: path, Err: err}
+	}
+
+	head := make([]byte, lineEndingSniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return fileInfo{}, &FileError{Op: "read", Path: path, Err: err}
+	}
+
+	detected, found := detectedMimetypes[path]
+	if !found {
+		detected = mimetype.Detect(head[:n]).String()
+	}
+
+	return fileInfo{
+		Path:       path,
+		Mimetype:   detected,
+		LineEnding: detectLineEnding(head[:n], n < len(head)),
+		Size:       uint64(stat.Size()),
+	}, nil
+}
+
+func genReportFileInfo(paths []string) (string, error) {
+	infos := make([]fileInfo, 0, len(paths))
+	for _, path := range paths {
+		info, err := collectFileInfo(path)
+		if err != nil {
+			return "", err
+		}
+		infos = append(infos, info)
+	}
+
+	const fileInfoTemplate = `
+File Info:
+{{range .}}{{paint "path" (formatPath .Path)}}: {{.Mimetype}}, line endings {{.LineEnding}}, {{bytes .Size}}
+{{end}}`
+
+	tmpl, err := template.New("fileinfo").Funcs(funcMap).Funcs(template.FuncMap{"bytes": humanize.Bytes}).Parse(fileInfoTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, infos)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
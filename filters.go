@@ -0,0 +1,242 @@
+package justbe
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// matchesGlob reports whether pattern matches either the base name or the
+// full path, so "*.org" and "notes/*.org" both work as expected.
+func matchesGlob(pattern string, path string) (bool, error) {
+	matched, err := filepath.Match(pattern, filepath.Base(path))
+	if err != nil || matched {
+		return matched, err
+	}
+	return filepath.Match(pattern, path)
+}
+
+// isIncluded reports whether path passes the --include patterns. Without
+// any include patterns every path is included.
+func isIncluded(path string) (bool, error) {
+	if len(opts.Include) == 0 {
+		return true, nil
+	}
+
+	for _, pattern := range opts.Include {
+		matched, err := matchesGlob(pattern, path)
+		if err != nil {
+			return false, fmt.Errorf("invalid include pattern %q: %v", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isExcluded reports whether path matches an --exclude pattern. Besides
+// the path itself, a path under the current directory is also tried
+// relative to it and through each directory in between, so "node_modules"
+// excludes everything inside any node_modules directory.
+func isExcluded(path string) (bool, error) {
+	if len(opts.Exclude) == 0 {
+		return false, nil
+	}
+
+	candidates := []string{path}
+	wd, err := os.Getwd()
+	absPath, absErr := filepath.Abs(path)
+	if err == nil && absErr == nil {
+		relPath, err := filepath.Rel(wd, absPath)
+		if err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			for dir := relPath; dir != "."; dir = filepath.Dir(dir) {
+				candidates = append(candidates, dir)
+			}
+		}
+	}
+
+	for _, pattern := range opts.Exclude {
+		for _, candidate := range candidates {
+			matched, err := matchesGlob(pattern, candidate)
+			if err != nil {
+				return false, fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// checkDuplicatePaths fails when two paths resolve to the same file, which
+// would otherwise count that file twice.
+func checkDuplicatePaths(paths []string) error {
+	seen := make(map[string]string)
+
+	for _, path := range paths {
+		resolved, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("error resolving path %s: %v", path, err)
+		}
+
+		if first, found := seen[resolved]; found {
+			return fmt.Errorf("path %s given more than once (as %s and %s)", resolved, first, path)
+		}
+		seen[resolved] = path
+	}
+
+	return nil
+}
+
+func filterIncluded(paths []string) ([]string, error) {
+	var included []string
+
+	for _, path := range paths {
+		ok, err := isIncluded(path)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			included = append(included, path)
+		}
+	}
+
+	return included, nil
+}
+
+func filterExcluded(paths []string) ([]string, error) {
+	var kept []string
+
+	for _, path := range paths {
+		excluded, err := isExcluded(path)
+		if err != nil {
+			return nil, err
+		}
+		if !excluded {
+			kept = append(kept, path)
+		}
+	}
+
+	return kept, nil
+}
+
+// firstMatchPerFile keeps the match with the lowest line number from each
+// file, preserving the order in which files first appear.
+func firstMatchPerFile(matches []MatchedLine) []MatchedLine {
+	index := make(map[string]int)
+	var firsts []MatchedLine
+
+	for _, match := range matches {
+		i, found := index[match.FilePath]
+		if !found {
+			index[match.FilePath] = len(firsts)
+			firsts = append(firsts, match)
+			continue
+		}
+
+		if match.LineNumber < firsts[i].LineNumber {
+			firsts[i] = match
+		}
+	}
+
+	return firsts
+}
+
+// matchesFromFirstFiles keeps the matches of the first n distinct files
+// in the order files first appear, which is input order for a scan.
+func matchesFromFirstFiles(matches []MatchedLine, n int) []MatchedLine {
+	files := make(map[string]bool)
+	var kept []MatchedLine
+
+	for _, match := range matches {
+		if !files[match.FilePath] {
+			if len(files) == n {
+				continue
+			}
+			files[match.FilePath] = true
+		}
+		kept = append(kept, match)
+	}
+
+	return kept
+}
+
+type overlap struct {
+	FilePath   string
+	LineNumber int
+	Count      int
+}
+
+// findOverlaps returns the positions that more than one match points at,
+// which happens when a file is listed twice or a pattern matches a line
+// more than once.
+func findOverlaps(matches []MatchedLine) []overlap {
+	type position struct {
+		path string
+		line int
+	}
+
+	counts := make(map[position]int)
+	var order []position
+
+	for _, match := range matches {
+		pos := position{match.FilePath, match.LineNumber}
+		if counts[pos] == 0 {
+			order = append(order, pos)
+		}
+		counts[pos]++
+	}
+
+	var overlaps []overlap
+	for _, pos := range order {
+		if counts[pos] > 1 {
+			overlaps = append(overlaps, overlap{FilePath: pos.path, LineNumber: pos.line, Count: counts[pos]})
+		}
+	}
+
+	return overlaps
+}
+
+// checkFileSizes fails on the first path larger than limit bytes, or with
+// skip set, drops such paths with a warning instead. With skipUnreadable
+// a path that cannot be stat'ed is kept rather than failing the run, so
+// the --skip-unreadable check after the filters records and drops it.
+func checkFileSizes(paths []string, limit uint64, skip bool, skipUnreadable bool) ([]string, error) {
+	var kept []string
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil && skipUnreadable {
+			kept = append(kept, path)
+			continue
+		}
+		if err != nil {
+			return nil, &FileError{Op: "open", Path: path, Err: err}
+		}
+
+		size := uint64(info.Size())
+		if size <= limit {
+			kept = append(kept, path)
+			continue
+		}
+
+		if skip {
+			slog.Warn("skipping file larger than size limit", "path", path,
+				"size", humanize.Bytes(size), "limit", humanize.Bytes(limit))
+			continue
+		}
+
+		return nil, &FileError{Op: "size", Path: path, Err: fmt.Errorf("%s, %w of %s",
+			humanize.Bytes(size), ErrFileTooLarge, humanize.Bytes(limit))}
+	}
+
+	return kept, nil
+}
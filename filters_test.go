@@ -0,0 +1,39 @@
+package justbe
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckFileSizes(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.org")
+	large := filepath.Join(dir, "large.org")
+	missing := filepath.Join(dir, "missing.org")
+	if err := os.WriteFile(small, []byte("* A tidbits\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(large, make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := checkFileSizes([]string{small, large}, 50, false, false); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("oversize err = %v, want ErrFileTooLarge", err)
+	}
+
+	var fileErr *FileError
+	if _, err := checkFileSizes([]string{small, missing}, 50, true, false); !errors.As(err, &fileErr) || fileErr.Path != missing {
+		t.Errorf("missing err = %v, want a *FileError for %s", err, missing)
+	}
+
+	kept, err := checkFileSizes([]string{small, large, missing}, 50, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{small, missing}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept = %q, want %q", kept, want)
+	}
+}
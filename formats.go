@@ -0,0 +1,237 @@
+package justbe
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sortMatchesByPosition orders matches by file, then by line number.
+func sortMatchesByPosition(matches []MatchedLine) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].FilePath != matches[j].FilePath {
+			return matches[i].FilePath < matches[j].FilePath
+		}
+		return matches[i].LineNumber < matches[j].LineNumber
+	})
+}
+
+// genReportGrep renders matches the way grep -n does so editors and other
+// tools that parse grep output can consume them. Fields are joined with
+// --field-sep and are not escaped.
+func genReportGrep(matches []MatchedLine) string {
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatchesByPosition(sortedMatches)
+
+	sep := opts.FieldSep
+
+	var b strings.Builder
+	for _, match := range sortedMatches {
+		text := match.Name
+		if opts.ShowRaw {
+			text = match.RawLine
+		}
+		if opts.Column {
+			fmt.Fprintf(&b, "%s%s%d%s%d%s%s\n", formatPath(match.FilePath), sep, match.LineNumber, sep, match.Column, sep, text)
+		} else {
+			fmt.Fprintf(&b, "%s%s%d%s%s\n", formatPath(match.FilePath), sep, match.LineNumber, sep, text)
+		}
+	}
+
+	return b.String()
+}
+
+var metricsLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// genReportMetrics renders stats in the Prometheus text exposition format,
+// suitable for the node_exporter textfile collector.
+func genReportMetrics(stats StatsReport) string {
+	var b strings.Builder
+
+	writeMetric := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", name)
+	}
+
+	writeFileSeries := func(name string, counts map[string]int) {
+		paths := make([]string, 0, len(counts))
+		for path := range counts {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			fmt.Fprintf(&b, "%s{path=\"%s\"} %d\n", name, metricsLabelEscaper.Replace(formatPath(path)), counts[path])
+		}
+	}
+
+	writeMetric("justbe_files", "Number of files scanned.")
+	fmt.Fprintf(&b, "justbe_files %d\n", stats.FileCount)
+
+	writeMetric("justbe_total_lines", "Total line count reported by the stats report.")
+	fmt.Fprintf(&b, "justbe_total_lines %d\n", stats.TotalLineCount)
+
+	writeMetric("justbe_total_matched_lines", "Total number of matched lines.")
+	fmt.Fprintf(&b, "justbe_total_matched_lines %d\n", stats.TotalMatchedLineCount)
+
+	writeMetric("justbe_file_lines", "Number of lines per file.")
+	writeFileSeries("justbe_file_lines", stats.FileLineCounts)
+
+	writeMetric("justbe_file_matched_lines", "Number of matched lines per file.")
+	writeFileSeries("justbe_file_matched_lines", stats.FileMatchedLineCounts)
+
+	return b.String()
+}
+
+// genReportChecklist renders matches as a Markdown task list, nesting each
+// item by its indent level. The path and line are joined with --field-sep.
+func genReportChecklist(matches []MatchedLine) string {
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatches(sortedMatches)
+
+	var b strings.Builder
+	for _, match := range sortedMatches {
+		indent := strings.Repeat("  ", max(match.IndentLevel-1, 0))
+		fmt.Fprintf(&b, "%s- [ ] %s (%s%s%d)\n", indent, match.Name, formatPath(match.FilePath), opts.FieldSep, match.LineNumber)
+	}
+
+	return b.String()
+}
+
+// genReportTOC numbers matches like an outline, 1, 1.1, 1.2, 2 and so on,
+// walking them in file and line order. Top level numbers continue from one
+// file to the next while deeper levels restart under each new parent. The
+// path and line are joined with --field-sep.
+func genReportTOC(matches []MatchedLine) string {
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatchesByPosition(sortedMatches)
+
+	var counters []int
+	var b strings.Builder
+
+	for _, entry := range buildOutline(sortedMatches) {
+		// entering a level resets every counter below it
+		counters = counters[:min(len(counters), entry.Depth)]
+		for len(counters) < entry.Depth {
+			counters = append(counters, 0)
+		}
+		counters[entry.Depth-1]++
+
+		numbers := make([]string, entry.Depth)
+		for i, counter := range counters {
+			numbers[i] = strconv.Itoa(counter)
+		}
+
+		match := entry.Match
+		fmt.Fprintf(&b, "%s%s %s (%s%s%d)\n", strings.Repeat("  ", entry.Depth-1),
+			strings.Join(numbers, "."), match.Name, formatPath(match.FilePath), opts.FieldSep, match.LineNumber)
+	}
+
+	return b.String()
+}
+
+type fileStatsJSON struct {
+	Path         string `json:"path"`
+	Lines        int    `json:"lines"`
+	MatchedLines int    `json:"matched_lines"`
+	SHA256       string `json:"sha256,omitempty"`
+}
+
+type totalStatsJSON struct {
+	Files        int `json:"files"`
+	Lines        int `json:"lines"`
+	MatchedLines int `json:"matched_lines"`
+}
+
+type statsJSON struct {
+	Files  []fileStatsJSON `json:"files"`
+	Totals totalStatsJSON  `json:"totals"`
+}
+
+// genReportStatsJSON renders stats as one object per file, sorted by path
+// so consecutive runs diff cleanly, followed by the totals.
+func genReportStatsJSON(stats StatsReport) (string, error) {
+	data, err := json.MarshalIndent(buildStatsJSON(stats), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding stats: %v", err)
+	}
+
+	return string(data), nil
+}
+
+func buildStatsJSON(stats StatsReport) statsJSON {
+	paths := make([]string, 0, len(stats.FileLineCounts))
+	for path := range stats.FileLineCounts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	doc := statsJSON{
+		Files: make([]fileStatsJSON, 0, len(paths)),
+		Totals: totalStatsJSON{
+			Files:        stats.FileCount,
+			Lines:        stats.TotalLineCount,
+			MatchedLines: stats.TotalMatchedLineCount,
+		},
+	}
+
+	for _, path := range paths {
+		doc.Files = append(doc.Files, fileStatsJSON{
+			Path:         formatPath(path),
+			Lines:        stats.FileLineCounts[path],
+			MatchedLines: stats.FileMatchedLineCounts[path],
+			SHA256:       stats.FileHashes[path],
+		})
+	}
+
+	return doc
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// genReportDot renders the heading hierarchy as a GraphViz digraph with
+// one node per match and an edge from each heading to its parent, with
+// the headings of each file grouped in a cluster.
+func genReportDot(matches []MatchedLine) string {
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatchesByPosition(sortedMatches)
+
+	entries := buildOutline(sortedMatches)
+
+	var b strings.Builder
+	b.WriteString("digraph justbe {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	cluster := 0
+	for i, entry := range entries {
+		if i == 0 || entry.Match.FilePath != entries[i-1].Match.FilePath {
+			if i > 0 {
+				b.WriteString("  }\n")
+			}
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n", cluster)
+			fmt.Fprintf(&b, "    label=\"%s\";\n", dotEscaper.Replace(formatPath(entry.Match.FilePath)))
+			cluster++
+		}
+		fmt.Fprintf(&b, "    n%d [label=\"%s\"];\n", i, dotEscaper.Replace(entry.Match.Name))
+	}
+	if len(entries) > 0 {
+		b.WriteString("  }\n")
+	}
+
+	for i, entry := range entries {
+		if entry.Parent >= 0 {
+			fmt.Fprintf(&b, "  n%d -> n%d;\n", entry.Parent, i)
+		}
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package justbe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// fileHashes maps each scanned path to the hex SHA-256 of its content. It
+// is nil unless --report-hashes is set.
+var fileHashes map[string]string
+
+// fileHashesMu guards fileHashes while files are scanned concurrently.
+var fileHashesMu sync.Mutex
+
+func recordHash(path string, sum string) {
+	fileHashesMu.Lock()
+	defer fileHashesMu.Unlock()
+	fileHashes[path] = sum
+}
+
+func recordedHash(path string) string {
+	fileHashesMu.Lock()
+	defer fileHashesMu.Unlock()
+	return fileHashes[path]
+}
+
+// hashFile streams path through SHA-256, for files whose matches came
+// from the cache and so were not read this run.
+func hashFile(path string) (string, error) {
+	file, err := openInput(path)
+	if err != nil {
+		return "", &FileError{Op: "open", Path: path, Err: err}
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", &FileError{Op: "read", Path: path, Err: fmt.Errorf("error hashing: %v", err)}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,59 @@
+package justbe
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+const htmlTableTemplate = `<table class="justbe-matches">
+<thead>
+<tr><th>Name</th><th>Level</th><th>Path</th><th>Line</th></tr>
+</thead>
+<tbody>
+{{- range . }}
+<tr><td>{{ .Name }}</td><td>{{ .IndentLevel }}</td><td>{{ formatPath .FilePath }}</td><td>{{ .LineNumber }}</td></tr>
+{{- end }}
+</tbody>
+</table>`
+
+const htmlPageTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>justbe matches</title>
+</head>
+<body>
+{{ template "table" . }}
+</body>
+</html>`
+
+// genReportHTML renders the matches, sorted by name, as an HTML table.
+// The table is wrapped in a complete page unless fragment is set, in which
+// case only the table is returned for embedding in another page.
+func genReportHTML(matches []MatchedLine, fragment bool) (string, error) {
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatches(sortedMatches)
+
+	tmpl, err := template.New("table").Funcs(funcMap).Parse(htmlTableTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	name := "table"
+	if !fragment {
+		name = "page"
+		if _, err := tmpl.New(name).Parse(htmlPageTemplate); err != nil {
+			return "", fmt.Errorf("error creating template: %v", err)
+		}
+	}
+
+	var b strings.Builder
+	err = tmpl.ExecuteTemplate(&b, name, sortedMatches)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,154 @@
+package justbe
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// defaultIgnoreFile is the --ignore-file default, which --recursive also
+// looks for in each directory it walks.
+const defaultIgnoreFile = ".justbeignore"
+
+// ignoreRules holds the gitignore style patterns of an ignore file. The
+// patterns are relative to root, the directory holding the file.
+// reincludes holds the patterns that start with "!", without it, so a
+// path they re-include can be told apart from one no pattern matches.
+type ignoreRules struct {
+	root       string
+	matcher    *gitignore.GitIgnore
+	reincludes *gitignore.GitIgnore
+}
+
+// loadIgnoreFile reads the ignore file at path. A missing file is not an
+// error and yields nil rules, which ignore nothing.
+func loadIgnoreFile(path string) (*ignoreRules, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving ignore file %s: %v", path, err)
+	}
+
+	data, err := os.ReadFile(absPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading ignore file %s: %v", path, err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	var reincludes []string
+	for _, line := range lines {
+		if line := strings.TrimSpace(line); strings.HasPrefix(line, "!") {
+			reincludes = append(reincludes, strings.TrimPrefix(line, "!"))
+		}
+	}
+
+	rules := &ignoreRules{root: filepath.Dir(absPath), matcher: gitignore.CompileIgnoreLines(lines...)}
+	if len(reincludes) > 0 {
+		rules.reincludes = gitignore.CompileIgnoreLines(reincludes...)
+	}
+
+	return rules, nil
+}
+
+// ignores reports whether path is excluded by the rules. Later patterns
+// win and a pattern starting with "!" re-includes what an earlier one
+// excluded. Paths outside the root are never ignored.
+func (r *ignoreRules) ignores(path string) bool {
+	ignored, _ := r.match(path, false)
+	return ignored
+}
+
+// match reports whether path is excluded by the rules and whether any
+// pattern matched it at all, so a path that is neither excluded nor
+// re-included is left to the rules of the enclosing directories. With
+// dir set it also covers patterns such as "build/" that only match
+// directories. Those are told apart from patterns such as "archive/*"
+// only by the path with a trailing slash, which both match, so with a
+// "!" pattern that may re-include a file inside, such directories are
+// still walked and their files checked one by one.
+func (r *ignoreRules) match(path string, dir bool) (ignored, matched bool) {
+	relPath, ok := r.relativePath(path)
+	if !ok {
+		return false, false
+	}
+
+	if r.matcher.MatchesPath(relPath) || (dir && r.reincludes == nil && r.matcher.MatchesPath(relPath+"/")) {
+		return true, true
+	}
+	if r.reincludes != nil && (r.reincludes.MatchesPath(relPath) || (dir && r.reincludes.MatchesPath(relPath+"/"))) {
+		return false, true
+	}
+	return false, false
+}
+
+// ignoreStack holds the rules of the ignore files that apply to a walked
+// directory, outermost first. The rules of a nested directory are checked
+// last, so they override those of the directories above it, as in git.
+type ignoreStack []*ignoreRules
+
+// ignores reports whether path, a directory when dir is set, is excluded
+// by the last rules in the stack that match it.
+func (s ignoreStack) ignores(path string, dir bool) bool {
+	ignored := false
+	for _, rules := range s {
+		if excluded, matched := rules.match(path, dir); matched {
+			ignored = excluded
+		}
+	}
+	return ignored
+}
+
+// contains reports whether path is inside the directory of the rules.
+func (r *ignoreRules) contains(path string) bool {
+	_, ok := r.relativePath(path)
+	return ok
+}
+
+// relativePath returns path relative to the root in slash form, or false
+// when there are no rules or path is outside the root.
+func (r *ignoreRules) relativePath(path string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+
+	relPath, err := filepath.Rel(r.root, absPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(os.PathSeparator)) {
+		return "", false
+	}
+
+	return filepath.ToSlash(relPath), true
+}
+
+func filterIgnored(paths []string, rules *ignoreRules) []string {
+	if rules == nil {
+		return paths
+	}
+
+	var kept []string
+	for _, path := range paths {
+		if rules.ignores(path) {
+			slog.Debug("skipping ignored file", "path", path)
+			continue
+		}
+		kept = append(kept, path)
+	}
+
+	return kept
+}
@@ -1,18 +1,29 @@
 package justbe
 
 import (
-	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"html/template"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
 
 	"github.com/dustin/go-humanize"
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/jessevdk/go-flags"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 
 	mymazda "github.com/taylormonacelli/forestfish/mymazda"
 )
@@ -21,26 +32,231 @@ var opts struct {
 	LogFormat string `long:"log-format" choice:"text" choice:"json" default:"text" description:"Log format"`
 	Verbose   []bool `short:"v" long:"verbose" description:"Show verbose debug information, each -v bumps log level"`
 	logLevel  slog.Level
-	Paths     []string `short:"p" long:"path" description:"File paths to be processed" required:"true"`
+	Paths     []string `short:"p" long:"path" description:"File paths to be processed" env:"JUSTBE_PATHS" env-delim:":"`
+	Recursive bool     `short:"r" long:"recursive" description:"Walk directories given with --path and process the text files found in them, skipping hidden and ignored files"`
+	MaxDepth  int      `long:"max-depth" description:"With --recursive, how many directory levels are walked, 1 takes only the files directly in each directory, 0 has no limit" default:"0"`
 
-	ReportMatches    bool `short:"m" long:"report-matches" description:"Generate report for matched lines"`
-	ReportStats      bool `short:"s" long:"report-stats" description:"Generate statistics report"`
-	ReportNameCounts bool `short:"n" long:"report-name-counts" description:"Generate report for name counts"`
+	FilesFromStdin bool `long:"files-from-stdin" description:"Read file paths to be processed from stdin, one per line"`
+	Stdin          bool `long:"stdin" description:"Scan the content piped to stdin, reported as (stdin), the same as a path of -"`
+
+	ReportMatches    bool `short:"m" long:"report-matches" description:"Generate report for matched lines (deprecated, use the matches command)"`
+	ReportStats      bool `short:"s" long:"report-stats" description:"Generate statistics report (deprecated, use the stats command)"`
+	ReportNameCounts bool `short:"n" long:"report-name-counts" description:"Generate report for name counts (deprecated, use the names command)"`
+	command          string
+	configReports    []string
+
+	// the options of each report command, only accepted after it
+	matchesOptions `no-flag:"true"`
+	statsOptions   `no-flag:"true"`
+	namesOptions   `no-flag:"true"`
+
+	Config string `long:"config" description:"YAML file of default options keyed by long flag name, flags given on the command line take precedence, defaults to ~/.config/justbe/config.yaml when it exists"`
+
+	Version bool `long:"version" description:"Print version and build information and exit"`
+
+	ErrorOnDupPath bool `long:"error-on-dup-path" description:"Fail when the same file is given more than once"`
+	RequireMatch   bool `long:"require-match" description:"Fail after reporting when any file has no matches, logging each such file"`
+
+	MinCount         int  `long:"min-count" description:"How many times a name must be found to count as a duplicate in the name counts report and for --fail-on-duplicates" default:"2"`
+	FailOnDuplicates bool `long:"fail-on-duplicates" description:"Fail after reporting when any name is found at least --min-count times, logging each such name"`
+
+	Include []string `long:"include" description:"Only scan files whose name or path matches this glob, may be repeated"`
+	Exclude []string `long:"exclude" description:"Skip files whose name or path, or the name of a directory they are in, matches this glob, such as node_modules or *_archive.org, may be repeated and wins over --include"`
+
+	ChangedSince string `long:"changed-since" description:"Only scan files that git reports as changed since this ref"`
+
+	IgnoreFile string `long:"ignore-file" default:".justbeignore" description:"Skip files matching the gitignore style patterns in this file, which are relative to its directory, a missing file ignores nothing, with --recursive the default .justbeignore of each walked directory also applies"`
+
+	Column bool `long:"column" description:"Add the 1-based column where the name starts to --format grep output, after the line number"`
+
+	FieldSep string `long:"field-sep" description:"Separator between fields in the flat formats, grep and the path and line of checklist and toc" default:":"`
+
+	LineStart int `long:"line-start" description:"First line, inclusive, that is matched in each file" default:"0"`
+	LineEnd   int `long:"line-end" description:"Last line, inclusive, that is matched in each file, 0 means the end of the file" default:"0"`
+
+	OutputDir   string `long:"output-dir" description:"Write a matches report for each input file into this directory"`
+	NoAggregate bool   `long:"no-aggregate" description:"With --output-dir, do not print the aggregate reports to stdout"`
+
+	SQLite string `long:"sqlite" description:"Write matches to a matches table in this SQLite database, requires a build with -tags sqlite"`
+	XLSX   string `long:"xlsx" description:"Write an Excel workbook with a summary sheet of name counts and one sheet of matches per file, requires a build with -tags xlsx"`
+
+	LineEnding string   `long:"line-ending" choice:"lf" choice:"cr" choice:"auto" default:"lf" description:"How lines are split: lf splits on LF and CRLF, cr also splits on a lone CR, auto uses cr when the start of a file has lone CRs"`
+	Keywords   []string `long:"keyword" default:"tidbits" description:"Word a heading must end with to match, may be repeated"`
+
+	Patterns          []string `long:"pattern" description:"Regexp that matches a heading, replacing the built-in pattern and --keyword, must have a (?P<name>...) group and may have indent, marker and keyword groups, may be repeated"`
+	Explain           bool     `long:"explain" description:"Print to stderr which pattern matched each heading and what its groups captured, disables --cache"`
+	ExplainNearMisses bool     `long:"explain-near-misses" description:"With --explain, also print lines that look like headings but matched no pattern"`
+
+	SectionPattern string `long:"section-pattern" description:"Regexp that extracts a section, its first group or whole match, from each name and removes it from the name, name counts are then grouped per section"`
+
+	DatePattern string `long:"date-pattern" description:"Regexp that extracts a date, its first group or whole match, from each name and removes it from the name, such as \\d{4}-\\d{2}-\\d{2}"`
+
+	UnescapeNewlines bool `long:"unescape-newlines" description:"Turn literal \\n sequences into real newlines before scanning, only in memory, files are never changed"`
+
+	IndentFrom string `long:"indent-from" choice:"marker" choice:"whitespace" default:"marker" description:"How the indent level of a heading is computed: marker counts the asterisks, whitespace also adds one level per tab and per two spaces before them"`
+
+	NormalizeIndent bool `long:"normalize-indent" description:"Rebase the indent levels of each file so its shallowest heading is level 1 and its smallest step between levels is one level"`
+
+	JoinContinuations  bool   `long:"join-continuations" description:"Join lines ending in the continuation marker with the next line before matching"`
+	ContinuationMarker string `long:"continuation-marker" description:"Marker at the end of a line that continues on the next line" default:"\\"`
+
+	Format string `long:"format" choice:"text" choice:"grep" choice:"metrics" choice:"checklist" choice:"toc" choice:"dot" choice:"html" choice:"svg" choice:"markdown" choice:"csv" choice:"json" choice:"protobuf" default:"text" description:"Output format, grep prints file:line:name per match, metrics prints stats for the Prometheus textfile collector, checklist prints a Markdown task list, toc prints an outline numbered table of contents, dot prints the heading hierarchy as a GraphViz graph, html prints an HTML page with a table of matches, svg prints a bar chart of the most frequent names, markdown prints the matches, name counts and stats reports as Markdown tables, csv prints the matches, or with the names report instead the name counts, as CSV, json prints a JSON document with the matches and, when those reports are selected, the name counts and stats, and protobuf writes a binary justbepb.Scan message instead of the text reports"`
+
+	ProtobufDelimited bool `long:"protobuf-delimited" description:"With --format protobuf, write one length-delimited justbepb.MatchedLine message per match instead of a single Scan"`
+
+	Output []string `long:"output" description:"Write the scan in a format to a path, as format:path with - for stdout, may be repeated and replaces --format"`
+
+	ReportAudit bool `long:"report-audit" description:"With --format json, wrap the matches in a document that also lists the processed files, the skipped files with the reason and the errors"`
+
+	HTMLFragment bool `long:"html-fragment" description:"With --format html, print only the table markup for embedding in an existing page"`
+
+	SVGTop int `long:"svg-top" description:"Number of names charted by --format svg, 0 charts all" default:"10"`
+
+	Anonymize  bool `long:"anonymize" description:"Replace file paths in reports with stable hashed identifiers"`
+	ShowLegend bool `long:"show-legend" description:"Print a legend mapping anonymized identifiers to file paths"`
+
+	GitRelative bool   `long:"git-relative" description:"Show file paths relative to the root of the enclosing git repository"`
+	PathStyle   string `long:"path-style" choice:"abs" choice:"rel" choice:"both" default:"abs" description:"How file paths are shown: abs as expanded from --path, rel relative to the current directory or with --git-relative to the repository root, both as rel followed by the absolute path in parentheses"`
+
+	ShowRaw bool `long:"show-raw" description:"Include the original matched line in the matches report and grep output"`
+
+	MaxNameWidth int `long:"max-name-width" description:"Truncate displayed names to this many characters, 0 disables truncation" default:"0"`
+
+	Sort string `long:"sort" choice:"name" choice:"input" choice:"date" default:"name" description:"Order of the matches reports, by name ignoring case, in input order, by path as given and then line, or by the --date-pattern date compared as text with undated matches last, ties keep input order"`
+
+	Workers int `long:"workers" description:"Number of files scanned at once, 0 uses one per CPU, results are still reported in input order" default:"0"`
+
+	Cache string `long:"cache" description:"Path to a cache file used to skip re-scanning unchanged files"`
+
+	WordFreq    bool   `long:"word-freq" description:"Generate report of the most frequent words across all files"`
+	WordFreqTop int    `long:"word-freq-top" description:"Number of words shown in the word frequency report" default:"20"`
+	Stopwords   string `long:"stopwords" description:"File with additional stopwords, one per line, excluded from word frequencies"`
+
+	TextExtensions []string `long:"text-ext" description:"File extension assumed to be text without mimetype detection, may be repeated; .org, .md and .txt are built in"`
+
+	Watch bool `long:"watch" description:"Keep running and print the reports again whenever a scanned file changes or a file is created next to one, rescanning only changed files"`
+
+	Timeout time.Duration `long:"timeout" description:"Give up if the run takes longer than this duration, e.g. 30s, 0 disables the timeout" default:"0"`
+
+	Exec string `long:"exec" description:"Shell command that receives each matched file's matches as JSON lines on stdin and the file's path as $1, its output is included in the report"`
+
+	Color string `long:"color" choice:"auto" choice:"always" choice:"never" default:"auto" description:"Colorize report output, auto only colorizes when stdout is a terminal"`
+
+	CRLF bool `long:"crlf" description:"Write reports with CRLF line endings"`
+
+	ReportMalformed bool `long:"report-malformed" description:"Generate report of heading-like lines that do not match the heading pattern"`
+
+	TemplateMatches string `long:"template-matches" description:"File with a Go template replacing the built-in matches report layout"`
+	TemplateStats   string `long:"template-stats" description:"File with a Go template replacing the built-in stats report layout"`
+	TemplateNames   string `long:"template-names" description:"File with a Go template replacing the built-in name counts report layout"`
+	TemplateDir     string `long:"template-dir" description:"Directory with matches.tmpl, stats.tmpl and names.tmpl templates replacing the built-in report layouts, each one optional, the --template-* flags take precedence"`
+
+	RenameMap string `long:"rename-map" description:"File of from=to lines, names matching a from side regardless of case are counted under the to side"`
+
+	Top int `long:"top" description:"Show only the N most frequent entries in the name counts and co-occurrence reports, 0 shows all" default:"0"`
+
+	ReportCooccurrence bool `long:"report-cooccurrence" description:"Generate report of name pairs that appear in the same files"`
+
+	NamesOnly        bool `long:"names-only" description:"Print the sorted, deduplicated match names one per line"`
+	CaseSensitive    bool `long:"case-sensitive" description:"Treat names that differ only in case as distinct when grouping"`
+	UnicodeNormalize bool `long:"unicode-normalize" description:"Group names after NFC normalization and Unicode case folding, so composed and decomposed forms of a name count as one"`
+
+	ReportNameLengths bool `long:"report-name-lengths" description:"Generate histogram of name lengths"`
+	BucketSize        int  `long:"bucket-size" description:"Width in characters of each name length histogram bucket" default:"10"`
+
+	ReportEmptyFiles bool `long:"report-empty-files" description:"Generate report of files without any matches"`
+
+	Interactive bool `long:"interactive" description:"Pick a match from a filterable list and print its path:line, only when stdin and stdout are terminals, otherwise the reports are printed as usual"`
+
+	Replace         bool   `long:"replace" description:"Rewrite each matched line in place with --replace-template instead of reporting"`
+	ReplaceTemplate string `long:"replace-template" default:"{{.Indent}}{{.Marker}} {{.Name}} {{.Keyword}}" description:"Go text/template for the new line, executed with each match, its original Indent and Marker text and the lower, upper, trim and repeat functions"`
+	Backup          bool   `long:"backup" description:"With --replace, save each file as file.bak before rewriting it"`
+
+	SkipUnreadable bool `long:"skip-unreadable" description:"Skip files that are not text or cannot be opened with a warning instead of failing, and list them after the reports"`
+	Lenient        bool `long:"lenient" description:"Same as --skip-unreadable"`
+
+	MaxFileSize  string `long:"max-file-size" description:"Fail when a file is larger than this size, such as 100MB"`
+	SkipOversize bool   `long:"skip-oversize" description:"With --max-file-size, skip larger files with a warning instead of failing"`
+
+	LiveCount bool `long:"live-count" description:"Show a running total of matches found on stderr while scanning, only when stderr is a terminal"`
+
+	MaxMatches int `long:"max-matches" default:"0" description:"Stop scanning once this many matches are found and report only those, 0 disables the limit"`
+
+	ReportMinLevels bool `long:"report-min-levels" description:"Generate report counting files by the shallowest indent level among their matches"`
+
+	ReportFirstRunes bool `long:"report-first-runes" description:"Generate report counting names that start with an uppercase letter, a lowercase letter, a digit or anything else"`
+
+	ReportStructureIssues bool `long:"report-structure-issues" description:"Generate report of headings nested more than one level deeper than the heading before them"`
+
+	ReportFileInfo bool `long:"report-file-info" description:"Generate report of the detected mimetype, line endings and size of each file"`
+
+	ReportByDir bool `long:"report-by-dir" description:"Generate report of match counts per directory"`
+
+	ReportLongestFiles bool `long:"report-longest-files" description:"Generate report of the files with the most lines"`
+	LongestFilesTop    int  `long:"longest-files-top" description:"Number of files shown in the longest files report" default:"10"`
+
+	ReportRecent bool `long:"report-recent" description:"Generate report of the matches in the most recently modified files first"`
+	RecentTop    int  `long:"recent-top" description:"Number of matches shown in the recent matches report, 0 shows all" default:"10"`
+
+	GroupByFirstWord bool `long:"group-by-first-word" description:"Generate report of match counts grouped by the first word of each name"`
+
+	WarnOverlap bool `long:"warn-overlap" description:"Warn when more than one match is found at the same file and line"`
+
+	FirstPerFile bool `long:"first-per-file" description:"Keep only the earliest match from each file"`
+
+	SaveScan string `long:"save-scan" description:"Write the scanned matches as JSON to this file for later use with --diff"`
+	Diff     string `long:"diff" description:"Compare the current scan against a JSON scan saved earlier"`
+
+	MergeAcrossRuns []string `long:"merge-across-runs" description:"Add the matches of an earlier scan saved with --save-scan or --format json before reporting, skipping any at a file and line already found, may be repeated"`
+}
+
+// matchesOptions are the options of the matches command.
+type matchesOptions struct {
+	KeywordLabels    []string `long:"keyword-label" description:"Label matches of a keyword in the matches report, as keyword=label, may be repeated"`
+	MaxFilesReported int      `long:"max-files-reported" description:"Show only the matches of the first N files with matches, in input order, in the matches report, other reports still cover every file, 0 shows all" default:"0"`
+}
+
+// statsOptions are the options of the stats command.
+type statsOptions struct {
+	ReportHashes bool   `long:"report-hashes" description:"Include the SHA-256 of each file in the stats report"`
+	StatsFormat  string `long:"stats-format" choice:"text" choice:"json-per-file" default:"text" description:"Layout of the stats report, json-per-file prints a JSON document with one object per file sorted by path and a totals object"`
+}
+
+// namesOptions are the options of the names command.
+type namesOptions struct {
+	NamesSort    string `long:"names-sort" choice:"count" choice:"name" default:"count" description:"Order of the name counts report, by descending count or alphabetically ignoring case"`
+	CountBuckets string `long:"count-buckets" description:"Split the name counts report into sections at these comma separated counts, so 10,5 gives sections for 10 or more, 5-9 and 2-4"`
+	Table        bool   `long:"table" description:"Render the name counts report as a table with a name column and right aligned counts"`
 }
 
 type MatchedLine struct {
-	FilePath    string
-	LineNumber  int
-	Name        string
-	IndentLevel int
+	FilePath    string `json:"file"`
+	LineNumber  int    `json:"line"`
+	Column      int    `json:"column"`
+	Name        string `json:"name"`
+	Section     string `json:"section,omitempty"`
+	Date        string `json:"date,omitempty"`
+	IndentLevel int    `json:"indent"`
+	Keyword     string `json:"keyword"`
+	RawLine     string `json:"raw_line"`
 }
 
 func formatNumWithCommas(num int) string {
 	return humanize.Comma(int64(num))
 }
 
+func inc(num int) int {
+	return num + 1
+}
+
 var funcMap = template.FuncMap{
 	"formatNumWithCommas": formatNumWithCommas,
+	"formatPath":          formatPath,
+	"formatName":          formatName,
+	"fitWidth":            fitWidth,
+	"alignRight":          alignRight,
+	"inc":                 inc,
+	"paint":               paint,
 }
 
 func Execute() int {
@@ -48,6 +264,16 @@ func Execute() int {
 		return 1
 	}
 
+	if opts.Version {
+		fmt.Println(versionString())
+		return 0
+	}
+
+	if len(opts.Paths) == 0 && !opts.FilesFromStdin && !opts.Stdin {
+		fmt.Fprintln(os.Stderr, "the required flag `-p, --path' was not specified")
+		return 1
+	}
+
 	if err := setLogLevel(); err != nil {
 		return 1
 	}
@@ -56,7 +282,15 @@ func Execute() int {
 		return 1
 	}
 
-	err := run(opts.Paths)
+	applyCommand()
+	setupColor()
+
+	scan := runWithTimeout
+	if opts.Watch {
+		scan = watch
+	}
+
+	err := scan(opts.Paths)
 	if err != nil {
 		slog.Error("run failed", "error", err)
 		return 1
@@ -65,125 +299,625 @@ func Execute() int {
 	return 0
 }
 
+var reportCommands = []struct {
+	name    string
+	short   string
+	title   string
+	options any
+}{
+	{"matches", "Generate report for matched lines", "Matches Report Options", &opts.matchesOptions},
+	{"stats", "Generate statistics report", "Stats Report Options", &opts.statsOptions},
+	{"names", "Generate report for name counts", "Names Report Options", &opts.namesOptions},
+}
+
+// parseFlags parses the command line into opts. The options of each
+// report belong to its command, but without a command, when the
+// deprecated -m, -s and -n flags or --format select several reports, all
+// of them are accepted at the root instead.
 func parseFlags() error {
-	_, err := flags.Parse(&opts)
-	return err
+	parser := flags.NewParser(&opts, flags.Default)
+	parser.SubcommandsOptional = true
+
+	// an option registered twice would have its default applied over the
+	// value given to the other, so each is registered in one place only
+	withCommand := reportCommandGiven()
+	for _, command := range reportCommands {
+		var options any = &struct{}{}
+		if withCommand {
+			options = command.options
+		}
+		_, err := parser.AddCommand(command.name, command.short, command.short, options)
+		if err != nil {
+			return err
+		}
+
+		if !withCommand {
+			_, err = parser.AddGroup(command.title, "", command.options)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := parser.Parse()
+	if err != nil {
+		return err
+	}
+
+	if parser.Active != nil {
+		opts.command = parser.Active.Name
+	}
+
+	if err := applyConfig(parser); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
+	return nil
+}
+
+// reportCommandGiven reports whether the command line names a report
+// command, found by a parse into a copy of opts that skips the options it
+// does not know, so report options before a command are not an error.
+func reportCommandGiven() bool {
+	probe := opts
+	parser := flags.NewParser(&probe, flags.IgnoreUnknown)
+	parser.SubcommandsOptional = true
+
+	for _, command := range reportCommands {
+		if _, err := parser.AddCommand(command.name, command.short, command.short, &struct{}{}); err != nil {
+			return false
+		}
+	}
+
+	if _, err := parser.Parse(); err != nil {
+		return false
+	}
+	return parser.Active != nil
+}
+
+// applyCommand selects the report for the active subcommand. Without one
+// the old -m, -s and -n flags still select reports, with a warning, and
+// without those the reports listed in the config file are selected.
+func applyCommand() {
+	switch opts.command {
+	case "matches":
+		opts.ReportMatches = true
+	case "stats":
+		opts.ReportStats = true
+	case "names":
+		opts.ReportNameCounts = true
+	default:
+		if opts.ReportMatches || opts.ReportStats || opts.ReportNameCounts {
+			slog.Warn("the -m, -s and -n flags are deprecated, use the matches, stats and names commands instead")
+			return
+		}
+		for _, report := range opts.configReports {
+			opts.command = report
+			applyCommand()
+		}
+	}
+}
+
+// runWithTimeout runs the scan, giving up once --timeout elapses. The scan
+// itself checks for cancellation between files, but a read stalled on a
+// slow filesystem cannot be interrupted, so the result is abandoned then.
+func runWithTimeout(paths []string) error {
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, paths)
+	}()
+
+	select {
+	case err := <-done:
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s", opts.Timeout)
+		}
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", opts.Timeout)
+	}
 }
 
-func run(paths []string) error {
+func run(ctx context.Context, paths []string) error {
+	targets, err := outputTargets()
+	if err != nil {
+		return err
+	}
+
+	if opts.MinCount < 1 {
+		return fmt.Errorf("invalid --min-count %d, expected at least 1", opts.MinCount)
+	}
+
+	if _, err := keywordLabels(opts.Keywords, opts.KeywordLabels); err != nil {
+		return err
+	}
+
+	if _, err := regexp.Compile(opts.SectionPattern); err != nil {
+		return fmt.Errorf("invalid --section-pattern %q: %v", opts.SectionPattern, err)
+	}
+
+	if _, err := regexp.Compile(opts.DatePattern); err != nil {
+		return fmt.Errorf("invalid --date-pattern %q: %v", opts.DatePattern, err)
+	}
+
+	if _, err := compileHeadingMatchers(scanOptions()); err != nil {
+		return err
+	}
+
+	renameMap = nil
+	if opts.RenameMap != "" {
+		renameMap, err = loadRenameMap(opts.RenameMap)
+		if err != nil {
+			return err
+		}
+	}
+
+	paths, readStdin := splitStdinPath(paths)
+	readStdin = readStdin || opts.Stdin
+	if readStdin && opts.FilesFromStdin {
+		return errors.New("--stdin and a path of - cannot be combined with --files-from-stdin, both read stdin")
+	}
+	if readStdin && opts.Replace {
+		return errors.New("--replace cannot rewrite stdin")
+	}
+
+	stdinContent = nil
+	if readStdin {
+		if err := readStdinContent(os.Stdin); err != nil {
+			return err
+		}
+	}
+
+	if opts.FilesFromStdin {
+		stdinPaths, err := readPathList(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("error reading paths from stdin: %v", err)
+		}
+		paths = append(paths, stdinPaths...)
+	}
+
 	expandedPaths, err := getAbsPath(paths...)
 	if err != nil {
 		return fmt.Errorf("error expanding paths: %v", err)
 	}
 
-	err = CanProcessFiles(expandedPaths...)
+	skippedFiles, auditErrors = nil, nil
+	detectedMimetypes = make(map[string]string)
+	skipUnreadableFiles := opts.SkipUnreadable || opts.Lenient
+
+	ignoreRules, err := loadIgnoreFile(opts.IgnoreFile)
 	if err != nil {
-		return fmt.Errorf("error asserting text files: %v", err)
+		return err
 	}
 
-	var matches []MatchedLine
+	if opts.Recursive {
+		// the default ignore file is also looked for in each walked
+		// directory, while one given with --ignore-file is used as is
+		dirIgnoreFile := ""
+		if opts.IgnoreFile == defaultIgnoreFile {
+			dirIgnoreFile = defaultIgnoreFile
+		}
+		expandedPaths, err = expandDirectories(expandedPaths, ignoreRules, dirIgnoreFile, opts.MaxDepth, skipUnreadableFiles)
+		if err != nil {
+			return err
+		}
+	}
 
-	// build matches from paths
-	for _, path := range expandedPaths {
-		if err := processFile(path, &matches); err != nil {
-			return fmt.Errorf("error processing file %s: %v", path, err)
+	if opts.ErrorOnDupPath {
+		if err := checkDuplicatePaths(expandedPaths); err != nil {
+			return err
 		}
 	}
 
-	if opts.ReportMatches {
-		reportMatches, err := genReportMatches(matches)
+	candidates := expandedPaths
+	expandedPaths, err = filterIncluded(expandedPaths)
+	if err != nil {
+		return fmt.Errorf("error applying include patterns: %v", err)
+	}
+	recordSkipped(candidates, expandedPaths, "not matched by --include")
+
+	candidates = expandedPaths
+	expandedPaths, err = filterExcluded(expandedPaths)
+	if err != nil {
+		return fmt.Errorf("error applying exclude patterns: %v", err)
+	}
+	recordSkipped(candidates, expandedPaths, "matched by --exclude")
+
+	candidates = expandedPaths
+	expandedPaths = filterIgnored(expandedPaths, ignoreRules)
+	recordSkipped(candidates, expandedPaths, "matched by the ignore file")
+
+	if opts.ChangedSince != "" {
+		candidates = expandedPaths
+		expandedPaths, err = filterChangedSince(expandedPaths, opts.ChangedSince)
 		if err != nil {
-			return fmt.Errorf("error printing matches: %v", err)
+			return err
 		}
-		fmt.Println(reportMatches)
+		recordSkipped(candidates, expandedPaths, "unchanged since "+opts.ChangedSince)
 	}
 
-	if opts.ReportNameCounts {
-		reportNameCounts, err := genReportNameCounts(matches)
+	if opts.MaxFileSize != "" {
+		limit, err := humanize.ParseBytes(opts.MaxFileSize)
 		if err != nil {
-			return fmt.Errorf("error printing name counts: %v", err)
+			return fmt.Errorf("invalid --max-file-size %q: %v", opts.MaxFileSize, err)
 		}
-		fmt.Println(reportNameCounts)
+
+		candidates = expandedPaths
+		expandedPaths, err = checkFileSizes(expandedPaths, limit, opts.SkipOversize, skipUnreadableFiles)
+		if err != nil {
+			return fmt.Errorf("error checking file sizes: %w", err)
+		}
+		recordSkipped(candidates, expandedPaths, "larger than --max-file-size")
+	}
+
+	// stdin has no name to filter on, so it is added past the filters
+	if readStdin {
+		expandedPaths = append(expandedPaths, stdinPath)
 	}
 
-	if opts.ReportStats {
-		reportStats, err := genReportStats(matches, expandedPaths)
+	if skipUnreadableFiles {
+		expandedPaths = skipUnreadable(expandedPaths)
+	} else {
+		err = CanProcessFiles(expandedPaths...)
 		if err != nil {
-			return fmt.Errorf("error printing stats: %v", err)
+			return fmt.Errorf("error asserting text files: %w", err)
 		}
-		fmt.Println(reportStats)
 	}
 
-	return nil
+	// a cached file is never rescanned, so it would go unexplained
+	cachePath := opts.Cache
+	if opts.Explain {
+		cachePath = ""
+	}
+
+	cache, err := openCache(cachePath)
+	if err != nil {
+		return fmt.Errorf("error opening cache: %v", err)
+	}
+	if cache == nil && !opts.Explain {
+		cache = watchCache
+	}
+
+	fileHashes = nil
+	if opts.ReportHashes {
+		fileHashes = make(map[string]string)
+	}
+
+	liveCount = nil
+	if opts.LiveCount && isTerminal(os.Stderr) {
+		liveCount = newLiveCounter(os.Stderr)
+	}
+
+	workers := opts.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	// explanations are written as files are scanned, so one file at a
+	// time keeps the lines of each file together
+	if opts.Explain {
+		workers = 1
+	}
+
+	scannedPaths = expandedPaths
+
+	matches, err := scanFiles(ctx, expandedPaths, cache, workers)
+	if err != nil {
+		return err
+	}
+
+	if opts.NormalizeIndent {
+		normalizeIndentLevels(matches)
+	}
+
+	liveCount.finish()
+
+	if cache != nil && cachePath != "" {
+		if err := cache.save(cachePath); err != nil {
+			return fmt.Errorf("error saving cache: %v", err)
+		}
+	}
+
+	if len(opts.MergeAcrossRuns) > 0 {
+		matches, err = mergeScans(matches, opts.MergeAcrossRuns)
+		if err != nil {
+			return fmt.Errorf("error merging earlier scans: %v", err)
+		}
+	}
+
+	if opts.WarnOverlap {
+		for _, overlap := range findOverlaps(matches) {
+			slog.Warn("multiple matches at the same position, check for duplicate paths or patterns",
+				"path", overlap.FilePath, "line", overlap.LineNumber, "count", overlap.Count)
+		}
+	}
+
+	if opts.FirstPerFile {
+		matches = firstMatchPerFile(matches)
+	}
+
+	if opts.Interactive {
+		if isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+			match, found, err := pickMatch(matches)
+			if err != nil {
+				return fmt.Errorf("error picking match: %v", err)
+			}
+			if found {
+				fmt.Printf("%s:%d\n", match.FilePath, match.LineNumber)
+			}
+			return nil
+		}
+		slog.Debug("stdin or stdout is not a terminal, skipping --interactive")
+	}
+
+	if opts.Replace {
+		if err := replaceMatches(matches, opts.ReplaceTemplate, opts.Backup); err != nil {
+			return fmt.Errorf("error replacing matches: %v", err)
+		}
+		return nil
+	}
+
+	if opts.SaveScan != "" {
+		if err := writeScanDocument(opts.SaveScan, matches); err != nil {
+			return fmt.Errorf("error saving scan: %v", err)
+		}
+	}
+
+	if opts.SQLite != "" {
+		if err := writeSQLite(opts.SQLite, matches); err != nil {
+			return fmt.Errorf("error writing sqlite database: %v", err)
+		}
+	}
+
+	if opts.XLSX != "" {
+		if err := writeXLSX(opts.XLSX, expandedPaths, matches); err != nil {
+			return fmt.Errorf("error writing xlsx workbook: %v", err)
+		}
+	}
+
+	if opts.OutputDir != "" {
+		if err := writeOutputDir(opts.OutputDir, expandedPaths, matches); err != nil {
+			return fmt.Errorf("error writing output directory: %v", err)
+		}
+	}
+
+	if opts.OutputDir == "" || !opts.NoAggregate {
+		for _, target := range targets {
+			if err := writeTarget(target, matches, expandedPaths); err != nil {
+				return err
+			}
+		}
+	}
+
+	if skipUnreadableFiles {
+		writeUnreadableSummary(os.Stderr)
+	}
+
+	// checked last so the reports of a failing run are still written
+	var checkErrs []error
+	if opts.RequireMatch {
+		checkErrs = append(checkErrs, requireMatches(expandedPaths, matches))
+	}
+	if opts.FailOnDuplicates {
+		checkErrs = append(checkErrs, failOnDuplicates(matches, opts.MinCount))
+	}
+
+	return errors.Join(checkErrs...)
 }
 
+// failOnDuplicates fails when any name is found at least minCount times,
+// logging each such name with its count.
+func failOnDuplicates(matches []MatchedLine, minCount int) error {
+	duplicates := 0
+	for _, info := range collectNameInfos(matches) {
+		if info.Count >= minCount {
+			slog.Error("duplicate name", "name", info.Name, "count", info.Count)
+			duplicates++
+		}
+	}
+
+	if duplicates == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w, %s found %d or more times", ErrDuplicateNames, formatNumWithCommas(duplicates), minCount)
+}
+
+// requireMatches fails when any of paths has no matches, logging each such
+// file so all of them can be fixed in one go.
+func requireMatches(paths []string, matches []MatchedLine) error {
+	empty := filesWithoutMatches(paths, matches)
+	if len(empty) == 0 {
+		return nil
+	}
+
+	for _, path := range empty {
+		slog.Error("file has no matches", "path", formatPath(path))
+	}
+
+	return fmt.Errorf("%s of %s files %w", formatNumWithCommas(len(empty)),
+		formatNumWithCommas(len(paths)), ErrNoMatches)
+}
+
+var builtinTextExtensions = []string{".org", ".md", ".txt"}
+
+// isAssumedText reports whether path has an extension known to be text, in
+// which case reading the file for mimetype detection can be skipped.
+func isAssumedText(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+
+	for _, known := range builtinTextExtensions {
+		if ext == known {
+			return true
+		}
+	}
+
+	for _, known := range opts.TextExtensions {
+		known = strings.ToLower(known)
+		if !strings.HasPrefix(known, ".") {
+			known = "." + known
+		}
+		if ext == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanProcessFiles checks that every path is a text file. Failures are
+// returned as a *FileError, wrapping ErrNotTextFile for binary files.
 func CanProcessFiles(paths ...string) error {
 	for _, path := range paths {
-		mimetype, err := mimetype.DetectFile(path)
-		if err != nil {
-			return fmt.Errorf("error detecting mimetype of file %s: %v", path, err)
+		if err := checkTextFile(path); err != nil {
+			return err
 		}
+	}
 
-		if mimetype.String() != "text/plain; charset=utf-8" {
-			return fmt.Errorf("file %s is not a text file", path)
-		}
+	return nil
+}
+
+// detectMimetype detects the mimetype of path, or of the stdin content for
+// stdinPath.
+func detectMimetype(path string) (*mimetype.MIME, error) {
+	if path == stdinPath {
+		return mimetype.Detect(stdinContent), nil
+	}
+	return mimetype.DetectFile(path)
+}
+
+// detectedMimetypes holds the mimetype checkTextFile detected for each
+// path in the current run, so the file info report need not detect it
+// again. Paths assumed to be text by their extension are not in it.
+var detectedMimetypes map[string]string
+
+func checkTextFile(path string) error {
+	if isAssumedText(path) {
+		return nil
+	}
+
+	mimetype, err := detectMimetype(path)
+	if err != nil {
+		return &FileError{Op: "detect", Path: path, Err: err}
+	}
+	if detectedMimetypes != nil {
+		detectedMimetypes[path] = mimetype.String()
+	}
+
+	if mimetype.String() != "text/plain; charset=utf-8" {
+		return &FileError{Op: "check", Path: path, Err: fmt.Errorf("%w, detected %s", ErrNotTextFile, mimetype.String())}
 	}
 
 	return nil
 }
 
-func processFile(path string, matches *[]MatchedLine) error {
-	file, err := os.Open(path)
+// processFile appends the matches in path, at most limit of them unless
+// limit is 0.
+func processFile(path string, limit int, matches *[]MatchedLine) error {
+	file, err := openInput(path)
 	if err != nil {
-		return fmt.Errorf("error opening file %s: %v", path, err)
+		return &FileError{Op: "open", Path: path, Err: err}
 	}
+	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	lineNumber := 0
+	scanOpts := scanOptions()
+	scanOpts.MaxMatches = limit
+	if liveCount != nil {
+		scanOpts.OnMatch = func(MatchedLine) { liveCount.add(1) }
+	}
+	if opts.Explain {
+		scanOpts.Explain = os.Stderr
+		scanOpts.ExplainNearMisses = opts.ExplainNearMisses
+	}
 
-	pattern := regexp.MustCompile(`(?i)^(\*+)\s+(.*)\s+tidbits$`)
+	// hashing what the scanner reads keeps it to a single pass over the
+	// file, the rest is drained below when the scan stops early
+	var reader io.Reader = file
+	h := sha256.New()
+	if fileHashes != nil {
+		reader = io.TeeReader(file, h)
+	}
 
-	for scanner.Scan() {
-		lineNumber++
-		line := scanner.Text()
-
-		if submatches := pattern.FindStringSubmatch(line); len(submatches) > 1 {
-			indentLevel := len(submatches[1])
-			name := strings.TrimSpace(submatches[2])
-			matchedLine := MatchedLine{
-				FilePath:    path,
-				LineNumber:  lineNumber,
-				Name:        name,
-				IndentLevel: indentLevel,
-			}
-			*matches = append(*matches, matchedLine)
-		}
+	fileMatches, err := ScanReader(reader, path, scanOpts)
+	if err != nil {
+		return err
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading file %s: %v", path, err)
+	if fileHashes != nil {
+		if _, err := io.Copy(h, file); err != nil {
+			return &FileError{Op: "read", Path: path, Err: err}
+		}
+		recordHash(path, hex.EncodeToString(h.Sum(nil)))
 	}
+	*matches = append(*matches, fileMatches...)
 
 	return nil
 }
 
 func genReportMatches(matches []MatchedLine) (string, error) {
+	if opts.MaxFilesReported > 0 {
+		matches = matchesFromFirstFiles(matches, opts.MaxFilesReported)
+	}
+
 	sortedMatches := make([]MatchedLine, len(matches))
 	copy(sortedMatches, matches)
-	sortMatchesByName(sortedMatches)
+	sortMatches(sortedMatches)
 
-	matchesTemplate := `
-{{range $index, $match := .}}
-{{printf "%5s. %s %s:%d" (formatNumWithCommas $index) $match.Name $match.FilePath $match.LineNumber}}{{end}}
-`
+	// indices are 1-based and padded to the widest one so columns line up
+	// past 10,000 entries where formatNumWithCommas adds separators
+	indexWidth := len(formatNumWithCommas(len(sortedMatches)))
+	if indexWidth < 5 {
+		indexWidth = 5
+	}
+
+	labels, err := keywordLabels(opts.Keywords, opts.KeywordLabels)
+	if err != nil {
+		return "", err
+	}
+
+	matchesTemplate, err := reportTemplate("matches")
+	if err != nil {
+		return "", err
+	}
 
 	tmpl, err := template.New("matches").Funcs(funcMap).Parse(matchesTemplate)
 	if err != nil {
 		return "", fmt.Errorf("error creating template: %v", err)
 	}
 
+	matchesData := struct {
+		Matches      []MatchedLine
+		IndexWidth   int
+		ShowRaw      bool
+		ShowKeywords bool
+		Label        func(string) string
+	}{
+		Matches:    sortedMatches,
+		IndexWidth: indexWidth,
+		ShowRaw:    opts.ShowRaw,
+		// with a single keyword and no labels every match would carry
+		// the same tag, so it is left out
+		ShowKeywords: len(opts.Keywords) > 1 || len(opts.KeywordLabels) > 0,
+		Label: func(keyword string) string {
+			if label, found := labels[strings.ToLower(keyword)]; found {
+				return label
+			}
+			return keyword
+		},
+	}
+
 	var b strings.Builder
-	err = tmpl.Execute(&b, sortedMatches)
+	err = tmpl.Execute(&b, matchesData)
 	if err != nil {
 		return "", fmt.Errorf("error executing template: %v", err)
 	}
@@ -191,14 +925,56 @@ func genReportMatches(matches []MatchedLine) (string, error) {
 	return b.String(), nil
 }
 
+// sortMatches orders matches for the matches reports. They are first put
+// in input order, by the position of each file's first match and then by
+// line, so ties in the name sort come out the same however the files were
+// scheduled.
+func sortMatches(matches []MatchedLine) {
+	sortMatchesByInput(matches)
+	switch opts.Sort {
+	case "name":
+		sortMatchesByName(matches)
+	case "date":
+		sortMatchesByDate(matches)
+	}
+}
+
+func sortMatchesByInput(matches []MatchedLine) {
+	fileIndex := make(map[string]int)
+	for _, match := range matches {
+		if _, found := fileIndex[match.FilePath]; !found {
+			fileIndex[match.FilePath] = len(fileIndex)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if fileIndex[matches[i].FilePath] != fileIndex[matches[j].FilePath] {
+			return fileIndex[matches[i].FilePath] < fileIndex[matches[j].FilePath]
+		}
+		return matches[i].LineNumber < matches[j].LineNumber
+	})
+}
+
+// sortMatchesByDate orders dated matches by their date as text, which is
+// chronological for dates written largest unit first such as 2024-01-15,
+// and puts undated matches after them.
+func sortMatchesByDate(matches []MatchedLine) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if (matches[i].Date == "") != (matches[j].Date == "") {
+			return matches[i].Date != ""
+		}
+		return matches[i].Date < matches[j].Date
+	})
+}
+
 func sortMatchesByName(matches []MatchedLine) {
-	sort.Slice(matches, func(i, j int) bool {
+	sort.SliceStable(matches, func(i, j int) bool {
 		return strings.ToLower(matches[i].Name) < strings.ToLower(matches[j].Name)
 	})
 }
 
 func countLinesInFile(path string) (int, error) {
-	file, err := os.Open(path)
+	file, err := openInput(path)
 	if err != nil {
 		slog.Warn("error opening file %s: %v", path, err)
 		return 0, fmt.Errorf("error opening file %s: %v", path, err)
@@ -214,7 +990,18 @@ func countLinesInFile(path string) (int, error) {
 	return lineCount, nil
 }
 
-func genReportStats(matches []MatchedLine, paths []string) (string, error) {
+// StatsReport holds the line and match counts behind the stats report.
+type StatsReport struct {
+	FileCount             int
+	FileLineCounts        map[string]int
+	TotalLineCount        int
+	FileMatchedLineCounts map[string]int
+	TotalMatchedLineCount int
+	// FileHashes holds the SHA-256 of each file with --report-hashes.
+	FileHashes map[string]string
+}
+
+func buildStatsReport(matches []MatchedLine, paths []string) StatsReport {
 	fileLineCounts := make(map[string]int)
 	fileMatchedLineCounts := make(map[string]int)
 	totalLineCount := 0
@@ -237,26 +1024,27 @@ func genReportStats(matches []MatchedLine, paths []string) (string, error) {
 		totalLineCount += lineCount
 	}
 
-	statsData := struct {
-		FileLineCounts        map[string]int
-		TotalLineCount        int
-		FileMatchedLineCounts map[string]int
-		TotalMatchedLineCount int
-	}{
+	return StatsReport{
+		FileCount:             len(fileLineCounts),
 		FileLineCounts:        fileLineCounts,
 		TotalLineCount:        totalLineCount,
 		FileMatchedLineCounts: fileMatchedLineCounts,
 		TotalMatchedLineCount: totalMatchedLineCount,
+		FileHashes:            fileHashes,
 	}
+}
 
-	statsTemplate := `
-File Line Counts:
-{{range $path, $count := .FileLineCounts}}{{printf "%10s: %s\n"  (formatNumWithCommas $count) $path}}{{end}}
-{{printf "%10s" (formatNumWithCommas .TotalLineCount)}}: Total Line Count
-{{range $path, $count := .FileMatchedLineCounts}}{{printf "%10s: %s: File Matched Line Counts"  (formatNumWithCommas $count) $path}}
-{{end}}
-{{ printf "%10s" (formatNumWithCommas .TotalMatchedLineCount)}}: Total Matched Line Count
-`
+func genReportStats(matches []MatchedLine, paths []string) (string, error) {
+	statsData := buildStatsReport(matches, paths)
+
+	// with no matches there is nothing to summarize, and any ratio over
+	// the matched totals would divide by zero, so the default template
+	// says so instead of printing empty tables. TotalLineCount is no test
+	// for that, as it counts each match on top of the lines of its file.
+	statsTemplate, err := reportTemplate("stats")
+	if err != nil {
+		return "", err
+	}
 
 	tmpl, err := template.New("stats").Funcs(funcMap).Parse(statsTemplate)
 	if err != nil {
@@ -273,12 +1061,12 @@ File Line Counts:
 }
 
 func countLines(path string) (int, error) {
-	file, err := os.Open(path)
+	file, err := openInput(path)
 	if err != nil {
 		return 0, fmt.Errorf("error opening file %s: %v", path, err)
 	}
 
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(file, opts.LineEnding)
 	lineCount := 0
 
 	for scanner.Scan() {
@@ -300,32 +1088,111 @@ func getAbsPath(paths ...string) ([]string, error) {
 		if err != nil {
 			return []string{}, fmt.Errorf("error expanding home directory in path %s: %v", path, err)
 		}
-		expandedPaths = append(expandedPaths, path)
+
+		matches, err := expandPathPattern(path)
+		if err != nil {
+			return []string{}, err
+		}
+		expandedPaths = append(expandedPaths, matches...)
 	}
 
 	return expandedPaths, nil
 }
 
-func genReportNameCounts(matches []MatchedLine) (string, error) {
-	type NameInfo struct {
-		Name   string
-		Count  int
-		Places []string
+type NameInfo struct {
+	Name   string
+	Count  int
+	Places []string
+}
+
+// nameKey returns the key under which a name is grouped with other names
+// that are considered the same.
+func nameKey(name string) string {
+	if opts.UnicodeNormalize {
+		name = norm.NFC.String(name)
+		if opts.CaseSensitive {
+			return name
+		}
+		return cases.Fold().String(name)
 	}
 
+	if opts.CaseSensitive {
+		return name
+	}
+	return strings.ToLower(name)
+}
+
+// sortNameInfosByName orders names alphabetically ignoring case, with
+// names equal but for case in byte order.
+func sortNameInfosByName(names []NameInfo) {
+	sort.SliceStable(names, func(i, j int) bool {
+		lower, upper := strings.ToLower(names[i].Name), strings.ToLower(names[j].Name)
+		if lower != upper {
+			return lower < upper
+		}
+		return names[i].Name < names[j].Name
+	})
+}
+
+// sortNameInfosByCount orders names by descending count. Equal counts are
+// ordered by name, ignoring case, so the output does not depend on map
+// iteration or input order.
+func sortNameInfosByCount(names []NameInfo) {
+	sort.SliceStable(names, func(i, j int) bool {
+		if names[i].Count != names[j].Count {
+			return names[i].Count > names[j].Count
+		}
+		lower, upper := strings.ToLower(names[i].Name), strings.ToLower(names[j].Name)
+		if lower != upper {
+			return lower < upper
+		}
+		return names[i].Name < names[j].Name
+	})
+}
+
+// maxTableNameWidth caps the name column of --table when
+// --max-name-width is not set, so one long name cannot push the counts
+// off screen.
+const maxTableNameWidth = 40
+
+// nameCountsColumnWidths sizes the --table columns to the longest name,
+// capped, and the widest count.
+func nameCountsColumnWidths(names []NameInfo) (nameWidth int, countWidth int) {
+	widthCap := maxTableNameWidth
+	if opts.MaxNameWidth > 0 {
+		widthCap = opts.MaxNameWidth
+	}
+
+	for _, info := range names {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(info.Name))
+		countWidth = max(countWidth, len(strconv.Itoa(info.Count)))
+	}
+
+	return min(nameWidth, widthCap), countWidth
+}
+
+// collectNameInfos groups matches by nameKey into one NameInfo per
+// distinct name, after --rename-map is applied, sorted by descending count.
+func collectNameInfos(matches []MatchedLine) []NameInfo {
 	nameCount := make(map[string]NameInfo)
 
 	for _, match := range matches {
-		lowerName := strings.ToLower(match.Name)
-		info, found := nameCount[lowerName]
+		name := canonicalName(match.Name)
+		key := nameKey(name)
+		if match.Section != "" {
+			key = nameKey(match.Section) + "\x00" + key
+			name = fmt.Sprintf("[%s] %s", match.Section, name)
+		}
+
+		info, found := nameCount[key]
 		if !found {
-			info = NameInfo{Name: match.Name}
+			info = NameInfo{Name: name}
 		}
 
 		info.Count++
-		info.Places = append(info.Places, fmt.Sprintf("%s:%d", match.FilePath, match.LineNumber))
+		info.Places = append(info.Places, fmt.Sprintf("%s:%d", formatPath(match.FilePath), match.LineNumber))
 
-		nameCount[lowerName] = info
+		nameCount[key] = info
 	}
 
 	names := make([]NameInfo, 0, len(nameCount))
@@ -333,43 +1200,84 @@ func genReportNameCounts(matches []MatchedLine) (string, error) {
 		names = append(names, info)
 	}
 
-	sort.Slice(names, func(i, j int) bool {
-		return names[i].Count > names[j].Count
-	})
+	sortNameInfosByCount(names)
+
+	return names
+}
+
+// duplicateNameInfos returns the names found at least --min-count times as
+// shown by the name counts report, limited by --top and ordered by
+// --names-sort, along with how many there are before --top.
+func duplicateNameInfos(matches []MatchedLine) ([]NameInfo, int) {
+	names := collectNameInfos(matches)
 
 	filteredNames := make([]NameInfo, 0)
 
 	for _, info := range names {
-		if info.Count >= 2 {
+		if info.Count >= opts.MinCount {
 			filteredNames = append(filteredNames, info)
 		}
 	}
 
-	sort.Slice(filteredNames, func(i, j int) bool {
-		return filteredNames[i].Count > filteredNames[j].Count
-	})
+	sortNameInfosByCount(filteredNames)
+
+	totalDuplicates := len(filteredNames)
+	if opts.Top > 0 && len(filteredNames) > opts.Top {
+		filteredNames = filteredNames[:opts.Top]
+	}
+
+	// --top keeps the most frequent names, so the order is only changed
+	// once they are picked
+	if opts.NamesSort == "name" {
+		sortNameInfosByName(filteredNames)
+	}
+
+	return filteredNames, totalDuplicates
+}
+
+func genReportNameCounts(matches []MatchedLine) (string, error) {
+	filteredNames, totalDuplicates := duplicateNameInfos(matches)
 
-	const namesTemplate = `
-Name duplicates (>= 2), total: {{ formatNumWithCommas .TotalDuplicates }}
-{{- range .Names }}
-{{ .Name }}: {{ .Count }}
-{{ range .Places -}}
-    {{ . }}
-{{ end -}}
-{{ end -}}
-`
+	nameWidth, countWidth := 0, 0
+	if opts.Table {
+		nameWidth, countWidth = nameCountsColumnWidths(filteredNames)
+	}
+
+	namesTemplate, err := reportTemplate("names")
+	if err != nil {
+		return "", err
+	}
 
 	tmpl, err := template.New("names").Funcs(funcMap).Parse(namesTemplate)
 	if err != nil {
 		return "", fmt.Errorf("error creating template: %v", err)
 	}
 
+	sections := []nameSection{{Names: filteredNames}}
+	if opts.CountBuckets != "" {
+		bounds, err := parseCountBuckets(opts.CountBuckets)
+		if err != nil {
+			return "", err
+		}
+		sections = bucketNamesByCount(filteredNames, bounds, opts.MinCount)
+	}
+
 	namesData := struct {
-		Names           []NameInfo
+		Sections        []nameSection
+		MinCount        int
 		TotalDuplicates int
+		Omitted         int
+		Table           bool
+		NameWidth       int
+		CountWidth      int
 	}{
-		Names:           filteredNames,
-		TotalDuplicates: len(filteredNames),
+		Sections:        sections,
+		MinCount:        opts.MinCount,
+		TotalDuplicates: totalDuplicates,
+		Omitted:         totalDuplicates - len(filteredNames),
+		Table:           opts.Table,
+		NameWidth:       nameWidth,
+		CountWidth:      countWidth,
 	}
 
 	var b strings.Builder
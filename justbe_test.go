@@ -0,0 +1,139 @@
+package justbe
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenReportMatchesIndex(t *testing.T) {
+	matches := make([]MatchedLine, 10001)
+	for i := range matches {
+		matches[i] = MatchedLine{FilePath: "notes.org", LineNumber: i + 1, Name: fmt.Sprintf("Name %05d", i), IndentLevel: 1}
+	}
+
+	report, err := genReportMatches(matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.Trim(report, "\n"), "\n")
+	if len(lines) != len(matches) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(matches))
+	}
+
+	if want := "     1. Name 00000 notes.org:1"; lines[0] != want {
+		t.Errorf("first line = %q, want %q", lines[0], want)
+	}
+	if want := "10,001. Name 10000 notes.org:10001"; lines[len(lines)-1] != want {
+		t.Errorf("last line = %q, want %q", lines[len(lines)-1], want)
+	}
+
+	column := strings.Index(lines[0], ". ")
+	for _, line := range lines {
+		if strings.Index(line, ". ") != column {
+			t.Fatalf("line %q is not aligned at column %d", line, column)
+		}
+	}
+}
+
+func TestGenReportMatchesUserTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matches.tmpl")
+	if err := os.WriteFile(path, []byte(`{{range .Matches}}{{.Name}} {{formatPath .FilePath}}{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := opts.TemplateMatches
+	opts.TemplateMatches = path
+	defer func() { opts.TemplateMatches = saved }()
+
+	report, err := genReportMatches([]MatchedLine{{FilePath: "a&b.org", LineNumber: 1, Name: "Tom & <Jerry>"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Tom & <Jerry> a&b.org"; report != want {
+		t.Errorf("report = %q, want %q", report, want)
+	}
+}
+
+func TestGenReportStatsEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.org")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := genReportStats(nil, []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Stats: no data (1 files, no matches)"; strings.TrimSpace(report) != want {
+		t.Errorf("report = %q, want %q", report, want)
+	}
+}
+
+func TestGenReportStatsNoMatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.org")
+	if err := os.WriteFile(path, []byte("intro\nbody\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := genReportStats(nil, []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Stats: no data (1 files, no matches)"; strings.TrimSpace(report) != want {
+		t.Errorf("report = %q, want %q", report, want)
+	}
+
+	report, err = genReportStats([]MatchedLine{{FilePath: path, LineNumber: 1, Name: "A"}}, []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(report, "no data") {
+		t.Errorf("report with a match = %q, want the counts", report)
+	}
+}
+
+func TestSortNameInfosByCountTies(t *testing.T) {
+	want := []string{"Zulu", "alpha", "Bravo", "bravo", "charlie", "Delta"}
+	counts := map[string]int{"Zulu": 3, "alpha": 2, "Bravo": 2, "bravo": 2, "charlie": 2, "Delta": 1}
+
+	random := rand.New(rand.NewSource(1))
+	for run := 0; run < 20; run++ {
+		names := make([]NameInfo, 0, len(want))
+		for _, name := range want {
+			names = append(names, NameInfo{Name: name, Count: counts[name]})
+		}
+		random.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+
+		sortNameInfosByCount(names)
+
+		for i, info := range names {
+			if info.Name != want[i] {
+				t.Fatalf("run %d: got %v, want %v", run, names, want)
+			}
+		}
+	}
+}
+
+func TestAnonymizePathUnique(t *testing.T) {
+	if anonymizePath("notes.org") != anonymizePath("notes.org") {
+		t.Fatal("anonymizePath is not stable")
+	}
+
+	seen := make(map[string]string)
+	for i := 0; i < 100000; i++ {
+		path := fmt.Sprintf("/notes/%d.org", i)
+		id := anonymizePath(path)
+		if other, found := seen[id]; found {
+			t.Fatalf("%s and %s share the id %s", path, other, id)
+		}
+		seen[id] = path
+	}
+}
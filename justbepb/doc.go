@@ -0,0 +1,5 @@
+// Package justbepb holds the protocol buffer messages written by
+// --format protobuf.
+package justbepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative justbe.proto
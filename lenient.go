@@ -0,0 +1,68 @@
+package justbe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// skipUnreadable returns the paths that are text files and can be opened,
+// logging and recording as audit errors the rest, for --skip-unreadable
+// runs that carry on without them.
+func skipUnreadable(paths []string) []string {
+	var readable []string
+
+	for _, path := range paths {
+		if err := checkReadable(path); err != nil {
+			recordUnreadable(path, err)
+			continue
+		}
+		readable = append(readable, path)
+	}
+
+	return readable
+}
+
+// checkReadable checks that path is a text file and opens it, since a file
+// assumed to be text by its extension is otherwise not opened until it is
+// scanned.
+func checkReadable(path string) error {
+	if err := checkTextFile(path); err != nil {
+		return err
+	}
+
+	file, err := openInput(path)
+	if err != nil {
+		return &FileError{Op: "open", Path: path, Err: err}
+	}
+	return file.Close()
+}
+
+// recordUnreadable logs err, the failure to check or read path, and adds
+// it to the audit errors.
+func recordUnreadable(path string, err error) {
+	slog.Warn("skipping unreadable file", "path", formatPath(path), "error", err)
+
+	op := "walk"
+	var fileErr *FileError
+	if errors.As(err, &fileErr) {
+		op = fileErr.Op
+	}
+
+	auditErrors = append(auditErrors, auditError{Path: path, Op: op, Error: err.Error()})
+}
+
+// writeUnreadableSummary lists the files skipped by --skip-unreadable, one
+// error per line, so they can be found after the reports scroll past the
+// warnings.
+func writeUnreadableSummary(w io.Writer) {
+	if len(auditErrors) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Skipped unreadable files, total: %s\n", formatNumWithCommas(len(auditErrors)))
+	for _, auditErr := range auditErrors {
+		fmt.Fprintf(w, "%s\n", auditErr.Error)
+	}
+}
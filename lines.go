@@ -0,0 +1,85 @@
+package justbe
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+const lineEndingSniffSize = 4096
+
+// scanAnyLines is a bufio.SplitFunc like bufio.ScanLines that also ends a
+// line at a lone CR, as written by classic Mac OS.
+func scanAnyLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+
+		// a CR at the end of the buffer may be the first half of a CRLF
+		return 0, nil, nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// hasLoneCR reports whether data contains a CR not followed by LF. A CR in
+// the last byte is ignored since the LF may simply not have been read yet.
+func hasLoneCR(data []byte) bool {
+	for i := 0; i < len(data)-1; i++ {
+		if data[i] == '\r' && data[i+1] != '\n' {
+			return true
+		}
+	}
+	return false
+}
+
+// lineSplitFunc returns the split function for lineEnding, one of the
+// --line-ending choices. head is the start of the input, which "auto"
+// inspects for lone CRs.
+func lineSplitFunc(lineEnding string, head []byte) bufio.SplitFunc {
+	switch lineEnding {
+	case "cr":
+		return scanAnyLines
+	case "auto":
+		if hasLoneCR(head) {
+			return scanAnyLines
+		}
+	}
+	return bufio.ScanLines
+}
+
+// newLineScanner returns a scanner over the lines of r, splitting them
+// according to lineEnding, one of the --line-ending choices.
+func newLineScanner(r io.Reader, lineEnding string) *bufio.Scanner {
+	var head []byte
+	if lineEnding == "auto" {
+		reader := bufio.NewReaderSize(r, lineEndingSniffSize)
+		head, _ = reader.Peek(lineEndingSniffSize)
+		r = reader
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Split(lineSplitFunc(lineEnding, head))
+
+	return scanner
+}
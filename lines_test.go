@@ -0,0 +1,45 @@
+package justbe
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanReaderCROnly(t *testing.T) {
+	const input = "intro\r* First tidbits\r\r** Second tidbits\rtrailing"
+
+	for _, lineEnding := range []string{"cr", "auto"} {
+		t.Run(lineEnding, func(t *testing.T) {
+			scanner := newLineScanner(strings.NewReader(input), lineEnding)
+			var lines []string
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(lines) != 5 {
+				t.Fatalf("got %d lines %q, want 5", len(lines), lines)
+			}
+
+			matches, err := ScanReader(strings.NewReader(input), "old-mac.org", Options{LineEnding: lineEnding})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := []struct {
+				line int
+				name string
+			}{{2, "First"}, {4, "Second"}}
+			if len(matches) != len(want) {
+				t.Fatalf("got %d matches, want %d", len(matches), len(want))
+			}
+			for i, match := range matches {
+				if match.LineNumber != want[i].line || match.Name != want[i].name {
+					t.Errorf("match %d = %s at line %d, want %s at line %d",
+						i, match.Name, match.LineNumber, want[i].name, want[i].line)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package justbe
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const liveCountInterval = 100 * time.Millisecond
+
+// liveCounter keeps a running match total on a single terminal line,
+// redrawing it at most once per liveCountInterval. A nil counter does
+// nothing, so callers need not check whether --live-count is on.
+type liveCounter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	total   int
+	drawnAt time.Time
+}
+
+// liveCount is the counter for the current run, nil unless --live-count
+// is set and stderr is a terminal.
+var liveCount *liveCounter
+
+func newLiveCounter(w io.Writer) *liveCounter {
+	return &liveCounter{w: w}
+}
+
+func (c *liveCounter) add(n int) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.total += n
+	if now := time.Now(); now.Sub(c.drawnAt) >= liveCountInterval {
+		c.draw()
+		c.drawnAt = now
+	}
+}
+
+// finish draws the final total and ends the line so later output starts
+// on a fresh one.
+func (c *liveCounter) finish() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.draw()
+	fmt.Fprintln(c.w)
+}
+
+func (c *liveCounter) draw() {
+	fmt.Fprintf(c.w, "\rmatches found: %s", formatNumWithCommas(c.total))
+}
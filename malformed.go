@@ -0,0 +1,85 @@
+package justbe
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// looseHeadingPatternFor accepts anything that looks like it was meant to
+// be a heading ending in one of keywords, such as a missing space after
+// the asterisks or an empty name.
+func looseHeadingPatternFor(keywords []string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)^\s*\*+.*(` + keywordAlternation(keywords) + `)\s*$`)
+}
+
+type MalformedLine struct {
+	FilePath   string
+	LineNumber int
+	Text       string
+}
+
+func findMalformedLines(path string) ([]MalformedLine, error) {
+	file, err := openInput(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var malformed []MalformedLine
+
+	options := scanOptions()
+	loosePattern := looseHeadingPatternFor(opts.Keywords)
+	matchers, err := compileHeadingMatchers(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// the lines are read as the scan reads them, so the line numbers of
+	// malformed headings agree with those of the matches
+	err = eachLine(file, path, options, func(lineNumber int, line string) bool {
+		if _, loc := findHeading(matchers, line); loosePattern.MatchString(line) && loc == nil {
+			malformed = append(malformed, MalformedLine{
+				FilePath:   path,
+				LineNumber: lineNumber,
+				Text:       line,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return malformed, nil
+}
+
+func genReportMalformed(paths []string) (string, error) {
+	var malformed []MalformedLine
+
+	for _, path := range paths {
+		lines, err := findMalformedLines(path)
+		if err != nil {
+			return "", err
+		}
+		malformed = append(malformed, lines...)
+	}
+
+	const malformedTemplate = `
+Malformed headings, total: {{ formatNumWithCommas (len .) }}
+{{range .}}{{printf "%s:%d: %s\n" (formatPath .FilePath) .LineNumber .Text}}{{end}}`
+
+	tmpl, err := template.New("malformed").Funcs(funcMap).Parse(malformedTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, malformed)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
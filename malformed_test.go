@@ -0,0 +1,56 @@
+package justbe
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindMalformedLinesNumbering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.org")
+	data := utf8BOM + "*First tidbits\n" + `intro\n*Second tidbits` + "\n* Good +\ntidbits\n*Third tidbits\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := opts
+	defer func() { opts = saved }()
+	opts.UnescapeNewlines = true
+	opts.JoinContinuations = true
+	opts.ContinuationMarker = "+"
+
+	malformed, err := findMalformedLines(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []MalformedLine{
+		{FilePath: path, LineNumber: 1, Text: "*First tidbits"},
+		{FilePath: path, LineNumber: 3, Text: "*Second tidbits"},
+		{FilePath: path, LineNumber: 6, Text: "*Third tidbits"},
+	}
+	if !reflect.DeepEqual(malformed, want) {
+		t.Errorf("malformed = %+v, want %+v", malformed, want)
+	}
+
+	matches, err := ScanReader(mustOpen(t, path), path, scanOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].LineNumber != 4 || matches[0].Name != "Good" {
+		t.Errorf("matches = %+v, want Good on line 4", matches)
+	}
+}
+
+func mustOpen(t *testing.T, path string) *os.File {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	return file
+}
@@ -0,0 +1,85 @@
+package justbe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var markdownEscaper = strings.NewReplacer(`|`, `\|`, "<", "&lt;", "\r", " ", "\n", " ")
+
+// genReportMarkdown renders the selected matches, name counts and stats
+// reports as GitHub flavored Markdown tables, each under its own heading.
+// Without any of them selected the matches table is rendered.
+func genReportMarkdown(matches []MatchedLine, paths []string) string {
+	showMatches := opts.ReportMatches || !opts.ReportNameCounts && !opts.ReportStats
+
+	var sections []string
+	if showMatches {
+		sections = append(sections, markdownMatches(matches))
+	}
+	if opts.ReportNameCounts {
+		sections = append(sections, markdownNameCounts(matches))
+	}
+	if opts.ReportStats {
+		sections = append(sections, markdownStats(buildStatsReport(matches, paths)))
+	}
+
+	return strings.Join(sections, "\n")
+}
+
+func markdownMatches(matches []MatchedLine) string {
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatches(sortedMatches)
+
+	var b strings.Builder
+	b.WriteString("## Matches\n\n")
+	b.WriteString("| # | Name | File | Line |\n")
+	b.WriteString("|--:|------|------|-----:|\n")
+	for i, match := range sortedMatches {
+		fmt.Fprintf(&b, "| %d | %s | %s | %d |\n", i+1, markdownEscaper.Replace(match.Name),
+			markdownEscaper.Replace(formatPath(match.FilePath)), match.LineNumber)
+	}
+
+	return b.String()
+}
+
+func markdownNameCounts(matches []MatchedLine) string {
+	names, _ := duplicateNameInfos(matches)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Name duplicates (>= %d)\n\n", opts.MinCount)
+	b.WriteString("| Name | Count | Places |\n")
+	b.WriteString("|------|------:|--------|\n")
+	for _, info := range names {
+		places := make([]string, len(info.Places))
+		for i, place := range info.Places {
+			places[i] = markdownEscaper.Replace(place)
+		}
+		fmt.Fprintf(&b, "| %s | %d | %s |\n", markdownEscaper.Replace(info.Name), info.Count, strings.Join(places, "<br>"))
+	}
+
+	return b.String()
+}
+
+func markdownStats(stats StatsReport) string {
+	paths := make([]string, 0, len(stats.FileLineCounts))
+	for path := range stats.FileLineCounts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	b.WriteString("## Stats\n\n")
+	b.WriteString("| File | Lines | Matched lines |\n")
+	b.WriteString("|------|------:|--------------:|\n")
+	for _, path := range paths {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownEscaper.Replace(formatPath(path)),
+			formatNumWithCommas(stats.FileLineCounts[path]), formatNumWithCommas(stats.FileMatchedLineCounts[path]))
+	}
+	fmt.Fprintf(&b, "| **Total** | %s | %s |\n", formatNumWithCommas(stats.TotalLineCount),
+		formatNumWithCommas(stats.TotalMatchedLineCount))
+
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package justbe
+
+// outlineEntry places a match in the heading hierarchy of its file.
+type outlineEntry struct {
+	Match MatchedLine
+	// Depth is 1 for a heading without ancestors.
+	Depth int
+	// Parent is the index of the nearest shallower heading before this
+	// one in the same file, or -1 when there is none.
+	Parent int
+}
+
+// buildOutline nests matches, which must be ordered by file and line, by
+// their indent level. A heading's parent is the closest preceding heading
+// in the same file with a smaller indent level, so skipped levels such as
+// a level 3 heading right under a level 1 heading still nest one deep.
+func buildOutline(matches []MatchedLine) []outlineEntry {
+	entries := make([]outlineEntry, 0, len(matches))
+	var stack []int
+	currentFile := ""
+
+	for i, match := range matches {
+		if i == 0 || match.FilePath != currentFile {
+			currentFile = match.FilePath
+			stack = stack[:0]
+		}
+
+		for len(stack) > 0 && entries[stack[len(stack)-1]].Match.IndentLevel >= match.IndentLevel {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := -1
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+
+		entries = append(entries, outlineEntry{
+			Match:  match,
+			Depth:  len(stack) + 1,
+			Parent: parent,
+		})
+		stack = append(stack, i)
+	}
+
+	return entries
+}
+
+// normalizeIndentLevels rebases the indent levels of each file so its
+// shallowest heading is level 1 and one step between levels counts as
+// one level. The step is the greatest common divisor of the gaps between
+// the distinct levels in the file, so levels 2, 4 and 6 become 1, 2 and
+// 3. When the gaps are ambiguous, as with levels 1, 2 and 4, the divisor
+// is 1 and only the rebase applies, keeping the larger gap as two levels
+// rather than guessing which headings were meant to be adjacent.
+func normalizeIndentLevels(matches []MatchedLine) {
+	type levelRange struct {
+		lowest int
+		step   int
+	}
+
+	lowest := make(map[string]int)
+	for _, match := range matches {
+		if level, found := lowest[match.FilePath]; !found || match.IndentLevel < level {
+			lowest[match.FilePath] = match.IndentLevel
+		}
+	}
+
+	ranges := make(map[string]levelRange, len(lowest))
+	for _, match := range matches {
+		r := ranges[match.FilePath]
+		r.lowest = lowest[match.FilePath]
+		r.step = gcd(r.step, match.IndentLevel-r.lowest)
+		ranges[match.FilePath] = r
+	}
+
+	for i := range matches {
+		r := ranges[matches[i].FilePath]
+		step := max(r.step, 1)
+		matches[i].IndentLevel = (matches[i].IndentLevel-r.lowest)/step + 1
+	}
+}
+
+func gcd(a int, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,117 @@
+package justbe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeOutput writes output to stdout, converting line endings to CRLF
+// when --crlf is set.
+func writeOutput(output string) error {
+	if opts.CRLF {
+		output = toCRLF(output)
+	}
+
+	_, err := fmt.Fprint(os.Stdout, output)
+	return err
+}
+
+func toCRLF(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+// outputFileName picks the report file name for path inside --output-dir.
+// Files sharing a basename get a numeric suffix so none overwrite another.
+func outputFileName(path string, used map[string]bool) string {
+	base := filepath.Base(path)
+	name := base + ".txt"
+
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s-%d.txt", base, n)
+	}
+	used[name] = true
+
+	return name
+}
+
+func writeOutputDir(dir string, paths []string, matches []MatchedLine) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating directory %s: %v", dir, err)
+	}
+
+	// files only get color when it is forced, terminal detection is about
+	// stdout and says nothing about who reads these files
+	savedColor := colorEnabled
+	colorEnabled = opts.Color == "always"
+	defer func() { colorEnabled = savedColor }()
+
+	byFile := make(map[string][]MatchedLine)
+	for _, match := range matches {
+		byFile[match.FilePath] = append(byFile[match.FilePath], match)
+	}
+
+	seen := make(map[string]bool)
+	used := make(map[string]bool)
+
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		report, err := genReportMatches(byFile[path])
+		if err != nil {
+			return fmt.Errorf("error generating report for %s: %v", path, err)
+		}
+
+		output := report + "\n"
+		if opts.CRLF {
+			output = toCRLF(output)
+		}
+
+		target := filepath.Join(dir, outputFileName(path, used))
+		if err := os.WriteFile(target, []byte(output), 0o644); err != nil {
+			return fmt.Errorf("error writing %s: %v", target, err)
+		}
+	}
+
+	return nil
+}
+
+// maxSheetNameLength is the longest sheet name Excel accepts.
+const maxSheetNameLength = 31
+
+var illegalSheetNameChars = strings.NewReplacer(
+	":", "_", `\`, "_", "/", "_", "?", "_", "*", "_", "[", "_", "]", "_",
+)
+
+// sheetName turns a file name into a sheet name Excel accepts: no
+// :\/?*[] characters, no leading or trailing apostrophe, at most 31
+// characters and unique in the workbook ignoring case. The used names,
+// kept lowercased, are updated.
+func sheetName(name string, used map[string]bool) string {
+	name = strings.Trim(illegalSheetNameChars.Replace(name), "'")
+	if name == "" || strings.EqualFold(name, "History") {
+		name = "Sheet"
+	}
+
+	candidate := truncateRunes(name, maxSheetNameLength)
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		candidate = truncateRunes(name, maxSheetNameLength-len(suffix)) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+
+	return candidate
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
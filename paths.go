@@ -0,0 +1,223 @@
+package justbe
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// readPathList reads one path per line from r, skipping blank lines. The
+// paths are expanded and checked later like paths given with --path.
+func readPathList(r io.Reader) ([]string, error) {
+	var paths []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		path := strings.TrimSpace(scanner.Text())
+		if path == "" {
+			continue
+		}
+		paths = append(paths, path)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading path list: %v", err)
+	}
+
+	return paths, nil
+}
+
+// expandBraces expands each {a,b} group in pattern into one pattern per
+// alternative, taking the cartesian product when there are several
+// groups, so "{a,b}/{x,y}" gives four patterns. Braces preceded by a
+// backslash are literal, as are groups without a comma and nested groups,
+// which are not supported.
+func expandBraces(pattern string) []string {
+	open, end := findBraceGroup(pattern)
+	if open < 0 {
+		return []string{pattern}
+	}
+
+	prefix, body, suffix := pattern[:open], pattern[open+1:end], pattern[end+1:]
+
+	var expanded []string
+	for _, alternative := range splitUnescaped(body, ',') {
+		expanded = append(expanded, expandBraces(prefix+alternative+suffix)...)
+	}
+
+	return expanded
+}
+
+// findBraceGroup returns the positions of the braces around the first
+// expandable group in pattern, or -1, -1 when there is none.
+func findBraceGroup(pattern string) (int, int) {
+	open := -1
+	hasComma := false
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			open, hasComma = i, false
+		case ',':
+			hasComma = hasComma || open >= 0
+		case '}':
+			if open >= 0 && hasComma {
+				return open, i
+			}
+			open = -1
+		}
+	}
+
+	return -1, -1
+}
+
+// splitUnescaped splits s at each sep not preceded by a backslash.
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+var braceUnescaper = strings.NewReplacer(`\{`, "{", `\}`, "}", `\,`, ",")
+
+// expandPathPattern brace expands pattern and globs each result, with
+// globStar when it has a ** segment and filepath.Glob otherwise. A result
+// without glob characters is kept with its brace escapes removed, and so
+// is one matching no files, so a mistyped path still fails as missing.
+func expandPathPattern(pattern string) ([]string, error) {
+	var paths []string
+
+	for _, expanded := range expandBraces(pattern) {
+		if !strings.ContainsAny(expanded, "*?[") {
+			paths = append(paths, braceUnescaper.Replace(expanded))
+			continue
+		}
+
+		glob := filepath.Glob
+		if strings.Contains(expanded, "**") {
+			glob = globStar
+		}
+
+		matches, err := glob(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %v", expanded, err)
+		}
+		if len(matches) == 0 {
+			paths = append(paths, braceUnescaper.Replace(expanded))
+			continue
+		}
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
+
+// globStar returns the files matching pattern, in which a ** segment
+// matches any number of directories, including none, so "notes/**/*.org"
+// matches notes/a.org as well as notes/x/y/a.org. As with a shell's
+// globstar, ** does not descend into hidden directories, which are not
+// walked at all unless a segment after the walk root starts with a dot.
+// The walk starts at the longest leading part of pattern without glob
+// characters.
+func globStar(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	static := 0
+	for static < len(segments)-1 && !strings.ContainsAny(segments[static], "*?[") {
+		static++
+	}
+
+	root := filepath.FromSlash(strings.Join(segments[:static], "/"))
+	switch {
+	case static == 0:
+		root = "."
+	case root == "":
+		root = string(filepath.Separator)
+	}
+
+	namesHidden := false
+	for _, segment := range segments[static:] {
+		namesHidden = namesHidden || strings.HasPrefix(segment, ".")
+	}
+
+	var matches []string
+	err := filepath.WalkDir(root, func(walked string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if walked == root && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if walked != root && !namesHidden && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, walked)
+		if err != nil {
+			return err
+		}
+		if matchSegments(segments[static:], strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, walked)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// ** pattern segment consumes any number of path segments not starting
+// with a dot.
+func matchSegments(pattern []string, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+			if i < len(segments) && strings.HasPrefix(segments[i], ".") {
+				return false
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	matched, _ := path.Match(pattern[0], segments[0])
+	return matched && matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,153 @@
+package justbe
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"notes.org", []string{"notes.org"}},
+		{"{a,b}.org", []string{"a.org", "b.org"}},
+		{"{a,b}/{x,y}.org", []string{"a/x.org", "a/y.org", "b/x.org", "b/y.org"}},
+		{"{a,b}{x,y}", []string{"ax", "ay", "bx", "by"}},
+		{"{a,}b", []string{"ab", "b"}},
+		{"{a}.org", []string{"{a}.org"}},
+		{"{}.org", []string{"{}.org"}},
+		{`\{a,b\}.org`, []string{`\{a,b\}.org`}},
+		{`{a\,b,c}.org`, []string{`a\,b.org`, "c.org"}},
+		{"{a}{b,c}", []string{"{a}b", "{a}c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := expandBraces(tt.pattern); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandBraces(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindBraceGroup(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		open, end int
+	}{
+		{"notes.org", -1, -1},
+		{"{a,b}", 0, 4},
+		{"x/{a,b}/y", 2, 6},
+		{"{a}{b,c}", 3, 7},
+		{"{a}", -1, -1},
+		{`\{a,b}`, -1, -1},
+		{`{a,b\}`, -1, -1},
+		{"a,{b}", -1, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			open, end := findBraceGroup(tt.pattern)
+			if open != tt.open || end != tt.end {
+				t.Errorf("findBraceGroup(%q) = %d, %d, want %d, %d", tt.pattern, open, end, tt.open, tt.end)
+			}
+		})
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"**/*.org", "a.org", true},
+		{"**/*.org", "x/y/a.org", true},
+		{"**/*.org", "x/a.txt", false},
+		{"notes/**/*.org", "notes/a.org", true},
+		{"notes/**/*.org", "notes/x/y/a.org", true},
+		{"notes/**/*.org", "other/a.org", false},
+		{"notes/**", "notes", true},
+		{"notes/**", "notes/x/a.org", true},
+		{"**/*.org", ".git/a.org", false},
+		{"**/*.org", "x/.hidden/a.org", false},
+		{"**/.hidden/*.org", "x/.hidden/a.org", true},
+		{"*/*.org", ".git/a.org", true},
+		{"a/*", "a/b/c", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			got := matchSegments(strings.Split(tt.pattern, "/"), strings.Split(tt.path, "/"))
+			if got != tt.want {
+				t.Errorf("matchSegments(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlobStar(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"a.org",
+		"b.txt",
+		"x/c.org",
+		"x/y/d.org",
+		".git/e.org",
+		"x/.hidden/f.org",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	abs := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(root, filepath.FromSlash(name)))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{"start", "**/*.org", []string{"a.org", filepath.FromSlash("x/c.org"), filepath.FromSlash("x/y/d.org")}},
+		{"middle", "x/**/*.org", []string{filepath.FromSlash("x/c.org"), filepath.FromSlash("x/y/d.org")}},
+		{"end", "x/**", []string{filepath.FromSlash("x/c.org"), filepath.FromSlash("x/y/d.org")}},
+		{"hidden segment", "**/.hidden/*.org", []string{filepath.FromSlash("x/.hidden/f.org")}},
+		{"absolute root", filepath.ToSlash(root) + "/**/*.org", abs("a.org", "x/c.org", "x/y/d.org")},
+		{"missing root", "missing/**/*.org", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := globStar(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("globStar(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
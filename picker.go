@@ -0,0 +1,139 @@
+package justbe
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// fuzzyMatch reports whether the runes of query appear in s in order,
+// ignoring case, so "wkt" matches "Work tidbits".
+func fuzzyMatch(query string, s string) bool {
+	s = strings.ToLower(s)
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+func pickerLabel(match MatchedLine) string {
+	return fmt.Sprintf("%s  %s:%d", match.Name, formatPath(match.FilePath), match.LineNumber)
+}
+
+// picker is the state of the interactive match list.
+type picker struct {
+	matches  []MatchedLine
+	query    string
+	filtered []MatchedLine
+	cursor   int
+}
+
+func (p *picker) filter() {
+	p.filtered = p.filtered[:0]
+	for _, match := range p.matches {
+		if fuzzyMatch(p.query, pickerLabel(match)) {
+			p.filtered = append(p.filtered, match)
+		}
+	}
+	p.cursor = min(p.cursor, max(len(p.filtered)-1, 0))
+}
+
+// draw renders the query line and as many matches as fit in height rows,
+// scrolled so the cursor stays visible. Raw mode needs explicit CRs.
+func (p *picker) draw(w io.Writer, height int) {
+	rows := max(height-1, 1)
+	top := max(p.cursor-rows+1, 0)
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "> %s  (%d/%d)\r\n", p.query, len(p.filtered), len(p.matches))
+	for i := top; i < len(p.filtered) && i < top+rows; i++ {
+		prefix := "  "
+		if i == p.cursor {
+			prefix = "> "
+		}
+		b.WriteString(prefix + pickerLabel(p.filtered[i]) + "\r\n")
+	}
+
+	fmt.Fprint(w, b.String())
+}
+
+// pickMatch shows the matches in a filterable list on the terminal and
+// returns the one chosen with enter. Typing filters the list, the arrow
+// keys or ctrl-p and ctrl-n move, and escape or ctrl-c cancel, in which
+// case found is false.
+func pickMatch(matches []MatchedLine) (match MatchedLine, found bool, err error) {
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatchesByPosition(sortedMatches)
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return MatchedLine{}, false, fmt.Errorf("error setting up terminal: %v", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	// the alternate screen keeps the list out of the scrollback
+	fmt.Fprint(os.Stdout, "\x1b[?1049h")
+	defer fmt.Fprint(os.Stdout, "\x1b[?1049l")
+
+	p := &picker{matches: sortedMatches}
+	p.filter()
+
+	buf := make([]byte, 64)
+	for {
+		_, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			height = 24
+		}
+		p.draw(os.Stdout, height)
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return MatchedLine{}, false, fmt.Errorf("error reading terminal input: %v", err)
+		}
+		input := string(buf[:n])
+
+		switch input {
+		case "\r", "\n":
+			if len(p.filtered) == 0 {
+				continue
+			}
+			return p.filtered[p.cursor], true, nil
+		case "\x1b", "\x03":
+			return MatchedLine{}, false, nil
+		case "\x1b[A", "\x10":
+			p.cursor = max(p.cursor-1, 0)
+			continue
+		case "\x1b[B", "\x0e":
+			p.cursor = min(p.cursor+1, max(len(p.filtered)-1, 0))
+			continue
+		case "\x7f", "\b":
+			if p.query != "" {
+				_, size := utf8.DecodeLastRuneInString(p.query)
+				p.query = p.query[:len(p.query)-size]
+			}
+		default:
+			if strings.HasPrefix(input, "\x1b") {
+				continue
+			}
+			for _, r := range input {
+				if unicode.IsPrint(r) {
+					p.query += string(r)
+				}
+			}
+		}
+
+		p.cursor = 0
+		p.filter()
+	}
+}
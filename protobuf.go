@@ -0,0 +1,87 @@
+package justbe
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/taylormonacelli/justbe/justbepb"
+)
+
+func toProtoMatch(match MatchedLine) *justbepb.MatchedLine {
+	return &justbepb.MatchedLine{
+		File:    formatPath(match.FilePath),
+		Line:    int32(match.LineNumber),
+		Column:  int32(match.Column),
+		Name:    match.Name,
+		Indent:  int32(match.IndentLevel),
+		Keyword: match.Keyword,
+		RawLine: match.RawLine,
+	}
+}
+
+func toProtoStats(stats StatsReport) *justbepb.Stats {
+	paths := make([]string, 0, len(stats.FileLineCounts))
+	for path := range stats.FileLineCounts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	message := &justbepb.Stats{
+		Files:        int64(stats.FileCount),
+		Lines:        int64(stats.TotalLineCount),
+		MatchedLines: int64(stats.TotalMatchedLineCount),
+	}
+	for _, path := range paths {
+		message.PerFile = append(message.PerFile, &justbepb.FileStats{
+			Path:         formatPath(path),
+			Lines:        int64(stats.FileLineCounts[path]),
+			MatchedLines: int64(stats.FileMatchedLineCounts[path]),
+		})
+	}
+
+	return message
+}
+
+// genReportProtobuf encodes the scan as a justbepb.Scan message. With
+// delimited set it instead writes one length-delimited justbepb.MatchedLine
+// per match, in file and line order, so readers can stream matches.
+func genReportProtobuf(matches []MatchedLine, paths []string, delimited bool) (string, error) {
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatchesByPosition(sortedMatches)
+
+	if delimited {
+		var b bytes.Buffer
+		for _, match := range sortedMatches {
+			if _, err := protodelim.MarshalTo(&b, toProtoMatch(match)); err != nil {
+				return "", fmt.Errorf("error encoding match: %v", err)
+			}
+		}
+		return b.String(), nil
+	}
+
+	scan := &justbepb.Scan{
+		Stats: toProtoStats(buildStatsReport(matches, paths)),
+	}
+	for _, match := range sortedMatches {
+		scan.Matches = append(scan.Matches, toProtoMatch(match))
+	}
+	for _, info := range collectNameInfos(matches) {
+		scan.NameCounts = append(scan.NameCounts, &justbepb.NameCount{
+			Name:   info.Name,
+			Count:  int64(info.Count),
+			Places: info.Places,
+		})
+	}
+
+	data, err := proto.Marshal(scan)
+	if err != nil {
+		return "", fmt.Errorf("error encoding scan: %v", err)
+	}
+
+	return string(data), nil
+}
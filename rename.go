@@ -0,0 +1,58 @@
+package justbe
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// renameMap maps lowercased names to the canonical names they are counted
+// under. It is nil unless --rename-map is set.
+var renameMap map[string]string
+
+// loadRenameMap reads from=to lines from path, one mapping per line. Blank
+// lines and lines starting with # are skipped, and whitespace around
+// either side is trimmed.
+func loadRenameMap(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening rename map %s: %v", path, err)
+	}
+	defer file.Close()
+
+	renames := make(map[string]string)
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		from, to, found := strings.Cut(line, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !found || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid rename map line %s:%d %q, expected from=to", path, lineNumber, line)
+		}
+
+		renames[strings.ToLower(from)] = to
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rename map %s: %v", path, err)
+	}
+
+	return renames, nil
+}
+
+// canonicalName returns the name that name is counted under, its mapping
+// in renameMap regardless of case or name itself when it has none.
+func canonicalName(name string) string {
+	if canonical, found := renameMap[strings.ToLower(name)]; found {
+		return canonical
+	}
+	return name
+}
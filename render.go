@@ -0,0 +1,302 @@
+package justbe
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// outputTarget is one --output destination. A path of "-" is stdout.
+type outputTarget struct {
+	Format string
+	Path   string
+}
+
+var outputFormats = []string{"text", "grep", "metrics", "checklist", "toc", "dot", "html", "svg", "markdown", "csv", "json", "protobuf"}
+
+// binaryFormats are written exactly as rendered, without --crlf.
+var binaryFormats = []string{"protobuf"}
+
+// outputTargets parses the --output specifications. Without any, output
+// goes to stdout in the --format format.
+func outputTargets() ([]outputTarget, error) {
+	if len(opts.Output) == 0 {
+		return []outputTarget{{Format: opts.Format, Path: "-"}}, nil
+	}
+
+	targets := make([]outputTarget, 0, len(opts.Output))
+	for _, spec := range opts.Output {
+		format, path, found := strings.Cut(spec, ":")
+		if !found || path == "" {
+			return nil, fmt.Errorf("invalid output %q, expected format:path", spec)
+		}
+
+		if !slices.Contains(outputFormats, format) {
+			return nil, fmt.Errorf("invalid output %q, unknown format %s, expected one of %s",
+				spec, format, strings.Join(outputFormats, ", "))
+		}
+
+		targets = append(targets, outputTarget{Format: format, Path: path})
+	}
+
+	return targets, nil
+}
+
+// writeTarget renders the scan in the target's format and writes it out.
+func writeTarget(target outputTarget, matches []MatchedLine, paths []string) error {
+	binary := slices.Contains(binaryFormats, target.Format)
+
+	if target.Path == "-" {
+		output, err := renderFormat(target.Format, matches, paths)
+		if err != nil {
+			return err
+		}
+		if binary {
+			_, err = os.Stdout.WriteString(output)
+		} else {
+			err = writeOutput(output)
+		}
+		if err != nil {
+			return fmt.Errorf("error writing report: %v", err)
+		}
+		return nil
+	}
+
+	// as with --output-dir, files only get color when it is forced
+	savedColor := colorEnabled
+	colorEnabled = opts.Color == "always"
+	defer func() { colorEnabled = savedColor }()
+
+	output, err := renderFormat(target.Format, matches, paths)
+	if err != nil {
+		return err
+	}
+	if opts.CRLF && !binary {
+		output = toCRLF(output)
+	}
+
+	if err := os.WriteFile(target.Path, []byte(output), 0o644); err != nil {
+		return fmt.Errorf("error writing %s: %v", target.Path, err)
+	}
+
+	return nil
+}
+
+// renderFormat renders a finished scan in one output format. The text
+// format holds every report selected on the command line.
+func renderFormat(format string, matches []MatchedLine, paths []string) (string, error) {
+	switch format {
+	case "grep":
+		return genReportGrep(matches), nil
+	case "checklist":
+		return genReportChecklist(matches), nil
+	case "html":
+		reportHTML, err := genReportHTML(matches, opts.HTMLFragment)
+		if err != nil {
+			return "", fmt.Errorf("error printing html: %v", err)
+		}
+		return reportHTML + "\n", nil
+	case "toc":
+		return genReportTOC(matches), nil
+	case "dot":
+		return genReportDot(matches), nil
+	case "csv":
+		return genReportCSV(matches)
+	case "markdown":
+		return genReportMarkdown(matches, paths), nil
+	case "svg":
+		return genReportSVG(matches, opts.SVGTop), nil
+	case "metrics":
+		return genReportMetrics(buildStatsReport(matches, paths)), nil
+	case "protobuf":
+		return genReportProtobuf(matches, paths, opts.ProtobufDelimited)
+	case "json":
+		var data []byte
+		var err error
+		if opts.ReportAudit {
+			data, err = encodeAuditDocument(matches, paths)
+		} else {
+			data, err = encodeReportDocument(matches, paths)
+		}
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+
+	return renderTextReports(matches, paths)
+}
+
+func renderTextReports(matches []MatchedLine, paths []string) (string, error) {
+	var b strings.Builder
+
+	if opts.ReportMatches {
+		reportMatches, err := genReportMatches(matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing matches: %v", err)
+		}
+		b.WriteString(reportMatches + "\n")
+	}
+
+	if opts.ReportNameCounts {
+		reportNameCounts, err := genReportNameCounts(matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing name counts: %v", err)
+		}
+		b.WriteString(reportNameCounts + "\n")
+	}
+
+	if opts.ReportStats {
+		var reportStats string
+		var err error
+		if opts.StatsFormat == "json-per-file" {
+			reportStats, err = genReportStatsJSON(buildStatsReport(matches, paths))
+		} else {
+			reportStats, err = genReportStats(matches, paths)
+		}
+		if err != nil {
+			return "", fmt.Errorf("error printing stats: %v", err)
+		}
+		b.WriteString(reportStats + "\n")
+	}
+
+	if opts.ReportMalformed {
+		reportMalformed, err := genReportMalformed(paths)
+		if err != nil {
+			return "", fmt.Errorf("error printing malformed lines: %v", err)
+		}
+		b.WriteString(reportMalformed + "\n")
+	}
+
+	if opts.NamesOnly {
+		b.WriteString(genReportNamesOnly(matches))
+	}
+
+	if opts.ReportCooccurrence {
+		reportCooccurrence, err := genReportCooccurrence(matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing co-occurrences: %v", err)
+		}
+		b.WriteString(reportCooccurrence + "\n")
+	}
+
+	if opts.ReportNameLengths {
+		reportNameLengths, err := genReportNameLengths(matches, opts.BucketSize)
+		if err != nil {
+			return "", fmt.Errorf("error printing name lengths: %v", err)
+		}
+		b.WriteString(reportNameLengths + "\n")
+	}
+
+	if opts.ReportEmptyFiles {
+		reportEmptyFiles, err := genReportEmptyFiles(paths, matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing empty files: %v", err)
+		}
+		b.WriteString(reportEmptyFiles + "\n")
+	}
+
+	if opts.ReportMinLevels {
+		reportMinLevels, err := genReportMinLevels(matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing min levels: %v", err)
+		}
+		b.WriteString(reportMinLevels + "\n")
+	}
+
+	if opts.ReportFirstRunes {
+		reportFirstRunes, err := genReportFirstRunes(matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing first characters: %v", err)
+		}
+		b.WriteString(reportFirstRunes + "\n")
+	}
+
+	if opts.ReportStructureIssues {
+		reportStructureIssues, err := genReportStructureIssues(matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing structure issues: %v", err)
+		}
+		b.WriteString(reportStructureIssues + "\n")
+	}
+
+	if opts.ReportFileInfo {
+		reportFileInfo, err := genReportFileInfo(paths)
+		if err != nil {
+			return "", fmt.Errorf("error printing file info: %v", err)
+		}
+		b.WriteString(reportFileInfo + "\n")
+	}
+
+	if opts.ReportByDir {
+		reportByDir, err := genReportByDir(matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing directory counts: %v", err)
+		}
+		b.WriteString(reportByDir + "\n")
+	}
+
+	if opts.ReportLongestFiles {
+		reportLongestFiles, err := genReportLongestFiles(paths)
+		if err != nil {
+			return "", fmt.Errorf("error printing longest files: %v", err)
+		}
+		b.WriteString(reportLongestFiles + "\n")
+	}
+
+	if opts.ReportRecent {
+		reportRecent, err := genReportRecent(matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing recent matches: %v", err)
+		}
+		b.WriteString(reportRecent + "\n")
+	}
+
+	if opts.GroupByFirstWord {
+		reportFirstWords, err := genReportFirstWords(matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing first word groups: %v", err)
+		}
+		b.WriteString(reportFirstWords + "\n")
+	}
+
+	if opts.WordFreq {
+		reportWordFreq, err := genReportWordFreq(paths)
+		if err != nil {
+			return "", fmt.Errorf("error printing word frequencies: %v", err)
+		}
+		b.WriteString(reportWordFreq + "\n")
+	}
+
+	if opts.Exec != "" {
+		reportExec, err := genReportExec(opts.Exec, matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing exec output: %v", err)
+		}
+		b.WriteString(reportExec + "\n")
+	}
+
+	if opts.Diff != "" {
+		baseline, err := readScanDocument(opts.Diff)
+		if err != nil {
+			return "", fmt.Errorf("error loading baseline: %v", err)
+		}
+
+		reportDiff, err := genReportDiff(baseline, matches)
+		if err != nil {
+			return "", fmt.Errorf("error printing diff: %v", err)
+		}
+		b.WriteString(reportDiff + "\n")
+	}
+
+	if opts.Anonymize && opts.ShowLegend {
+		reportLegend, err := genReportLegend(paths)
+		if err != nil {
+			return "", fmt.Errorf("error printing legend: %v", err)
+		}
+		b.WriteString(reportLegend + "\n")
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,222 @@
+package justbe
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+var replaceFuncMap = template.FuncMap{
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"trim":   strings.TrimSpace,
+	"repeat": strings.Repeat,
+}
+
+// lineReplacement is the new text for a line along with the text the line
+// held when it was scanned.
+type lineReplacement struct {
+	Old string
+	New string
+}
+
+// rewriteLines replaces the lines of data numbered in replacements,
+// counting lines the same way ScanReader does. Every other byte,
+// including each line's own ending and a leading byte order mark, is
+// kept as it was. A line that no longer holds the text it was scanned
+// with, because the file changed since, fails the rewrite, as does a line
+// past the end of data.
+func rewriteLines(data []byte, lineEnding string, replacements map[int]lineReplacement) ([]byte, error) {
+	head := data
+	if len(head) > lineEndingSniffSize {
+		head = head[:lineEndingSniffSize]
+	}
+	split := lineSplitFunc(lineEnding, head)
+
+	var b strings.Builder
+	b.Grow(len(data))
+
+	lineNumber := 0
+	for pos := 0; pos < len(data); {
+		advance, token, _ := split(data[pos:], true)
+		if advance == 0 {
+			break
+		}
+		lineNumber++
+
+		replacement, found := replacements[lineNumber]
+		if !found {
+			b.Write(data[pos : pos+advance])
+			pos += advance
+			continue
+		}
+
+		line := string(token)
+		if lineNumber == 1 && strings.HasPrefix(line, utf8BOM) {
+			b.WriteString(utf8BOM)
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
+		if line != replacement.Old {
+			return nil, fmt.Errorf("line %d changed since it was scanned, %q is not the matched heading %q", lineNumber, line, replacement.Old)
+		}
+		b.WriteString(replacement.New)
+		b.Write(data[pos+len(token) : pos+advance])
+		pos += advance
+	}
+
+	for number := range replacements {
+		if number > lineNumber {
+			return nil, fmt.Errorf("line %d is past the end of the file, which has %d lines", number, lineNumber)
+		}
+	}
+
+	return []byte(b.String()), nil
+}
+
+// writeFileAtomic replaces path with data by writing a temporary file in
+// the same directory and renaming it over path, so readers see either the
+// old or the new content. The file keeps its permissions.
+func writeFileAtomic(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error reading file info %s: %v", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file for %s: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing %s: %v", tmp.Name(), err)
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error setting permissions on %s: %v", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing %s: %v", tmp.Name(), err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error replacing %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// replacement is what --replace-template is executed with: the match along
+// with the whitespace and marker the heading was written with, so a
+// template can keep them as they were.
+type replacement struct {
+	MatchedLine
+	Indent string
+	Marker string
+}
+
+// replaceMatches rewrites each matched line with the result of executing
+// replaceTemplate on its match. With backup set, each changed file is
+// first copied to path.bak.
+func replaceMatches(matches []MatchedLine, replaceTemplate string, backup bool) error {
+	if opts.JoinContinuations {
+		return fmt.Errorf("--replace cannot be used with --join-continuations, a match may span several lines")
+	}
+	if opts.UnescapeNewlines {
+		return fmt.Errorf("--replace cannot be used with --unescape-newlines, matches do not refer to lines of the file")
+	}
+	if opts.NormalizeIndent {
+		return fmt.Errorf("--replace cannot be used with --normalize-indent, the levels written back would not be those of the file")
+	}
+	if opts.DatePattern != "" || opts.SectionPattern != "" {
+		return fmt.Errorf("--replace cannot be used with --date-pattern or --section-pattern, the text they cut from the name would be lost")
+	}
+	if len(opts.MergeAcrossRuns) > 0 || opts.Diff != "" {
+		return fmt.Errorf("--replace cannot be used with --merge-across-runs or --diff, earlier scans refer to lines the files may no longer have")
+	}
+
+	matchers, err := compileHeadingMatchers(scanOptions())
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New("replace").Funcs(replaceFuncMap).Parse(replaceTemplate)
+	if err != nil {
+		return fmt.Errorf("error creating template: %v", err)
+	}
+
+	var paths []string
+	byFile := make(map[string]map[int]lineReplacement)
+
+	for _, match := range matches {
+		data := replacement{MatchedLine: match}
+		if matcher, loc := findHeading(matchers, match.RawLine); loc != nil {
+			data.Indent = group(match.RawLine, loc, matcher.indent)
+			data.Marker = group(match.RawLine, loc, matcher.marker)
+		}
+
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return fmt.Errorf("error executing template: %v", err)
+		}
+
+		line := b.String()
+		if strings.ContainsAny(line, "\r\n") {
+			return fmt.Errorf("replacement for %s:%d contains a line break", match.FilePath, match.LineNumber)
+		}
+
+		if _, found := byFile[match.FilePath]; !found {
+			paths = append(paths, match.FilePath)
+			byFile[match.FilePath] = make(map[int]lineReplacement)
+		}
+		byFile[match.FilePath][match.LineNumber] = lineReplacement{Old: match.RawLine, New: line}
+	}
+
+	// every file is checked before any is written, so a file that changed
+	// since the scan leaves all of them untouched
+	type rewrite struct {
+		path     string
+		original []byte
+		data     []byte
+	}
+	var rewrites []rewrite
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading file %s: %v", path, err)
+		}
+
+		rewritten, err := rewriteLines(data, opts.LineEnding, byFile[path])
+		if err != nil {
+			return fmt.Errorf("error rewriting %s: %v", path, err)
+		}
+		if string(rewritten) != string(data) {
+			rewrites = append(rewrites, rewrite{path: path, original: data, data: rewritten})
+		}
+	}
+
+	for _, r := range rewrites {
+		if backup {
+			info, err := os.Stat(r.path)
+			if err != nil {
+				return fmt.Errorf("error reading file info %s: %v", r.path, err)
+			}
+			if err := os.WriteFile(r.path+".bak", r.original, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("error writing backup %s.bak: %v", r.path, err)
+			}
+		}
+
+		if err := writeFileAtomic(r.path, r.data); err != nil {
+			return err
+		}
+
+		slog.Info("rewrote file", "path", r.path, "lines", len(byFile[r.path]))
+	}
+
+	return nil
+}
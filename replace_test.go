@@ -0,0 +1,142 @@
+package justbe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRewriteLines(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		lineEnding   string
+		replacements map[int]lineReplacement
+		want         string
+	}{
+		{
+			name:         "lf",
+			data:         "intro\n* Old tidbits\nbody\n",
+			replacements: map[int]lineReplacement{2: {Old: "* Old tidbits", New: "* New tidbits"}},
+			want:         "intro\n* New tidbits\nbody\n",
+		},
+		{
+			name:         "crlf",
+			data:         "intro\r\n* Old tidbits\r\nbody\r\n",
+			replacements: map[int]lineReplacement{2: {Old: "* Old tidbits", New: "* New tidbits"}},
+			want:         "intro\r\n* New tidbits\r\nbody\r\n",
+		},
+		{
+			name:         "cr only",
+			data:         "intro\r* Old tidbits\rbody\r",
+			lineEnding:   "cr",
+			replacements: map[int]lineReplacement{2: {Old: "* Old tidbits", New: "* New tidbits"}},
+			want:         "intro\r* New tidbits\rbody\r",
+		},
+		{
+			name:         "bom on line 1",
+			data:         utf8BOM + "* Old tidbits\nbody\n",
+			replacements: map[int]lineReplacement{1: {Old: "* Old tidbits", New: "* New tidbits"}},
+			want:         utf8BOM + "* New tidbits\nbody\n",
+		},
+		{
+			name:         "last line without newline",
+			data:         "intro\n* Old tidbits",
+			replacements: map[int]lineReplacement{2: {Old: "* Old tidbits", New: "* New tidbits"}},
+			want:         "intro\n* New tidbits",
+		},
+		{
+			name:         "unmatched lines are byte identical",
+			data:         "  trailing space \n\tx\r\n\n* Old tidbits\n\xff\xfe\n",
+			replacements: map[int]lineReplacement{4: {Old: "* Old tidbits", New: "* New tidbits"}},
+			want:         "  trailing space \n\tx\r\n\n* New tidbits\n\xff\xfe\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rewriteLines([]byte(tt.data), tt.lineEnding, tt.replacements)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteLinesChanged(t *testing.T) {
+	data := []byte("new first line\n* Old tidbits\n")
+
+	if _, err := rewriteLines(data, "", map[int]lineReplacement{1: {Old: "* Old tidbits", New: "* New tidbits"}}); err == nil {
+		t.Error("rewrote a line that no longer holds the matched heading")
+	}
+	if _, err := rewriteLines(data, "", map[int]lineReplacement{5: {Old: "* Old tidbits", New: "* New tidbits"}}); err == nil {
+		t.Error("rewrote a line past the end of the file")
+	}
+}
+
+func TestReplaceMatchesBackup(t *testing.T) {
+	saved := opts
+	t.Cleanup(func() { opts = saved })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.org")
+	original := "intro\n\t** Standup tidbits\nbody\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, err := ScanReader(file, path, scanOptions())
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replaceMatches(matches, "{{.Indent}}{{.Marker}} {{upper .Name}} {{.Keyword}}", true); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "intro\n\t** STANDUP tidbits\nbody\n"; string(got) != want {
+		t.Errorf("rewritten file = %q, want %q", got, want)
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != original {
+		t.Errorf("backup = %q, want %q", backup, original)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("rewritten file mode = %v, want %v", info.Mode().Perm(), os.FileMode(0o600))
+	}
+
+	// the temporary file is renamed over the original, so only the file
+	// and its backup remain
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("directory holds %v, want only the file and its backup", names)
+	}
+}
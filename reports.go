@@ -0,0 +1,605 @@
+package justbe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+func genReportFirstWords(matches []MatchedLine) (string, error) {
+	groups := make(map[string]NameInfo)
+
+	for _, match := range matches {
+		fields := strings.Fields(match.Name)
+		if len(fields) == 0 {
+			continue
+		}
+
+		key := nameKey(fields[0])
+		info, found := groups[key]
+		if !found {
+			info = NameInfo{Name: fields[0]}
+		}
+		info.Count++
+		groups[key] = info
+	}
+
+	words := make([]NameInfo, 0, len(groups))
+	for _, info := range groups {
+		words = append(words, info)
+	}
+	sortNameInfosByCount(words)
+
+	const firstWordsTemplate = `
+First word groups, total: {{ formatNumWithCommas (len .) }}
+{{range .}}{{printf "%10s: %s\n" (formatNumWithCommas .Count) .Name}}{{end}}`
+
+	tmpl, err := template.New("firstwords").Funcs(funcMap).Parse(firstWordsTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, words)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+func genReportLongestFiles(paths []string) (string, error) {
+	type FileInfo struct {
+		Path      string
+		LineCount int
+	}
+
+	seen := make(map[string]bool)
+	files := make([]FileInfo, 0, len(paths))
+
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		lineCount, err := countLinesInFile(path)
+		if err != nil {
+			continue
+		}
+		files = append(files, FileInfo{Path: path, LineCount: lineCount})
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].LineCount > files[j].LineCount
+	})
+
+	if opts.LongestFilesTop > 0 && len(files) > opts.LongestFilesTop {
+		files = files[:opts.LongestFilesTop]
+	}
+
+	const longestFilesTemplate = `
+Longest files:
+{{range .}}{{printf "%10s: %s\n" (formatNumWithCommas .LineCount) (formatPath .Path)}}{{end}}`
+
+	tmpl, err := template.New("longestfiles").Funcs(funcMap).Parse(longestFilesTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, files)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+// genReportRecent lists the matches of the most recently modified files
+// first, each file stat'ed once, and within a file by line. A file that
+// can no longer be stat'ed sorts last.
+func genReportRecent(matches []MatchedLine) (string, error) {
+	type recentMatch struct {
+		Match   MatchedLine
+		ModTime time.Time
+	}
+
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatchesByInput(sortedMatches)
+
+	modTimes := make(map[string]time.Time)
+	recent := make([]recentMatch, 0, len(sortedMatches))
+	for _, match := range sortedMatches {
+		modTime, found := modTimes[match.FilePath]
+		if !found {
+			if info, err := os.Stat(match.FilePath); err == nil {
+				modTime = info.ModTime()
+			}
+			modTimes[match.FilePath] = modTime
+		}
+		recent = append(recent, recentMatch{Match: match, ModTime: modTime})
+	}
+
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].ModTime.After(recent[j].ModTime)
+	})
+
+	if opts.RecentTop > 0 && len(recent) > opts.RecentTop {
+		recent = recent[:opts.RecentTop]
+	}
+
+	const recentTemplate = `
+Recent matches:
+{{range .}}{{.ModTime.Format "2006-01-02 15:04"}}  {{paint "name" (formatName .Match.Name)}} {{paint "path" (printf "%s:%d" (formatPath .Match.FilePath) .Match.LineNumber)}}
+{{end}}`
+
+	tmpl, err := template.New("recent").Funcs(funcMap).Parse(recentTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, recent)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+func genReportNamesOnly(matches []MatchedLine) string {
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(matches))
+
+	for _, match := range matches {
+		key := nameKey(match.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, match.Name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		lower, upper := strings.ToLower(names[i]), strings.ToLower(names[j])
+		if lower != upper {
+			return lower < upper
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func genReportByDir(matches []MatchedLine) (string, error) {
+	dirCount := make(map[string]int)
+	for _, match := range matches {
+		dirCount[filepath.Dir(match.FilePath)]++
+	}
+
+	dirs := make([]NameInfo, 0, len(dirCount))
+	for dir, count := range dirCount {
+		dirs = append(dirs, NameInfo{Name: dir, Count: count})
+	}
+
+	sortNameInfosByCount(dirs)
+
+	const byDirTemplate = `
+Matches per directory:
+{{range .}}{{printf "%10s: %s\n" (formatNumWithCommas .Count) (formatPath .Name)}}{{end}}`
+
+	tmpl, err := template.New("bydir").Funcs(funcMap).Parse(byDirTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, dirs)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+func genReportNameLengths(matches []MatchedLine, bucketSize int) (string, error) {
+	type Bucket struct {
+		Low   int
+		High  int
+		Count int
+	}
+
+	if bucketSize < 1 {
+		return "", fmt.Errorf("bucket size must be at least 1, got %d", bucketSize)
+	}
+
+	// bucket n holds names of length n*size+1 through (n+1)*size, so with
+	// the default size the buckets are 1-10, 11-20 and so on
+	bucketCount := make(map[int]int)
+	for _, match := range matches {
+		length := utf8.RuneCountInString(match.Name)
+		if length == 0 {
+			continue
+		}
+		bucketCount[(length-1)/bucketSize]++
+	}
+
+	buckets := make([]Bucket, 0, len(bucketCount))
+	for n, count := range bucketCount {
+		buckets = append(buckets, Bucket{
+			Low:   n*bucketSize + 1,
+			High:  (n + 1) * bucketSize,
+			Count: count,
+		})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Low < buckets[j].Low
+	})
+
+	const nameLengthsTemplate = `
+Name lengths:
+{{range .}}{{printf "%10s: %d-%d chars\n" (formatNumWithCommas .Count) .Low .High}}{{end}}`
+
+	tmpl, err := template.New("namelengths").Funcs(funcMap).Parse(nameLengthsTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, buckets)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+func genReportCooccurrence(matches []MatchedLine) (string, error) {
+	type Pair struct {
+		First  string
+		Second string
+		Count  int
+	}
+
+	names := make(map[string]string)
+	fileNames := make(map[string]map[string]bool)
+
+	for _, match := range matches {
+		key := nameKey(match.Name)
+		if _, found := names[key]; !found {
+			names[key] = match.Name
+		}
+		if fileNames[match.FilePath] == nil {
+			fileNames[match.FilePath] = make(map[string]bool)
+		}
+		fileNames[match.FilePath][key] = true
+	}
+
+	pairCount := make(map[[2]string]int)
+	for _, keys := range fileNames {
+		sortedKeys := make([]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+
+		for i := 0; i < len(sortedKeys); i++ {
+			for j := i + 1; j < len(sortedKeys); j++ {
+				pairCount[[2]string{sortedKeys[i], sortedKeys[j]}]++
+			}
+		}
+	}
+
+	pairs := make([]Pair, 0, len(pairCount))
+	for keys, count := range pairCount {
+		pairs = append(pairs, Pair{First: names[keys[0]], Second: names[keys[1]], Count: count})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Count != pairs[j].Count {
+			return pairs[i].Count > pairs[j].Count
+		}
+		if !strings.EqualFold(pairs[i].First, pairs[j].First) {
+			return strings.ToLower(pairs[i].First) < strings.ToLower(pairs[j].First)
+		}
+		return strings.ToLower(pairs[i].Second) < strings.ToLower(pairs[j].Second)
+	})
+
+	totalPairs := len(pairs)
+	if opts.Top > 0 && len(pairs) > opts.Top {
+		pairs = pairs[:opts.Top]
+	}
+
+	const cooccurrenceTemplate = `
+Name co-occurrences, total pairs: {{ formatNumWithCommas .TotalPairs }}
+{{range .Pairs}}{{printf "%10s: %s | %s\n" (formatNumWithCommas .Count) .First .Second}}{{end}}`
+
+	tmpl, err := template.New("cooccurrence").Funcs(funcMap).Parse(cooccurrenceTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	cooccurrenceData := struct {
+		Pairs      []Pair
+		TotalPairs int
+	}{
+		Pairs:      pairs,
+		TotalPairs: totalPairs,
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, cooccurrenceData)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+// filesWithoutMatches returns the scanned paths that produced no matches,
+// sorted and without duplicates.
+func filesWithoutMatches(paths []string, matches []MatchedLine) []string {
+	matched := make(map[string]bool)
+	for _, match := range matches {
+		matched[match.FilePath] = true
+	}
+
+	var empty []string
+	for _, path := range paths {
+		if !matched[path] {
+			matched[path] = true
+			empty = append(empty, path)
+		}
+	}
+	sort.Strings(empty)
+
+	return empty
+}
+
+func genReportEmptyFiles(paths []string, matches []MatchedLine) (string, error) {
+	const emptyFilesTemplate = `
+Files without matches, total: {{ formatNumWithCommas (len .) }}
+{{range .}}{{formatPath .}}
+{{end}}`
+
+	tmpl, err := template.New("emptyfiles").Funcs(funcMap).Parse(emptyFilesTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, filesWithoutMatches(paths, matches))
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+// structureIssue is a heading nested more than one level deeper than the
+// heading before it in the same file.
+type structureIssue struct {
+	Match         MatchedLine
+	PreviousLevel int
+}
+
+func findStructureIssues(matches []MatchedLine) []structureIssue {
+	sortedMatches := make([]MatchedLine, len(matches))
+	copy(sortedMatches, matches)
+	sortMatchesByPosition(sortedMatches)
+
+	var issues []structureIssue
+	for i, match := range sortedMatches {
+		if i == 0 || sortedMatches[i-1].FilePath != match.FilePath {
+			continue
+		}
+
+		previousLevel := sortedMatches[i-1].IndentLevel
+		if match.IndentLevel-previousLevel > 1 {
+			issues = append(issues, structureIssue{Match: match, PreviousLevel: previousLevel})
+		}
+	}
+
+	return issues
+}
+
+func genReportStructureIssues(matches []MatchedLine) (string, error) {
+	const structureIssuesTemplate = `
+Structure issues, total: {{ formatNumWithCommas (len .) }}
+{{range .}}{{formatPath .Match.FilePath}}:{{.Match.LineNumber}}: level {{.PreviousLevel}} to {{.Match.IndentLevel}}: {{.Match.Name}}
+{{end}}`
+
+	tmpl, err := template.New("structureissues").Funcs(funcMap).Parse(structureIssuesTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, findStructureIssues(matches))
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+// genReportMinLevels counts files by the shallowest indent level among
+// their matches, showing how consistently files start their headings at
+// the same level. Files without matches are not counted.
+func genReportMinLevels(matches []MatchedLine) (string, error) {
+	fileMinLevel := make(map[string]int)
+	for _, match := range matches {
+		level, found := fileMinLevel[match.FilePath]
+		if !found || match.IndentLevel < level {
+			fileMinLevel[match.FilePath] = match.IndentLevel
+		}
+	}
+
+	levelCount := make(map[int]int)
+	for _, level := range fileMinLevel {
+		levelCount[level]++
+	}
+
+	type LevelCount struct {
+		Level int
+		Files int
+	}
+
+	levels := make([]LevelCount, 0, len(levelCount))
+	for level, files := range levelCount {
+		levels = append(levels, LevelCount{Level: level, Files: files})
+	}
+
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].Level < levels[j].Level
+	})
+
+	const minLevelsTemplate = `
+Files by shallowest match level:
+{{range .}}{{printf "level %d: %s files\n" .Level (formatNumWithCommas .Files)}}{{end}}`
+
+	tmpl, err := template.New("minlevels").Funcs(funcMap).Parse(minLevelsTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, levels)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+var firstRuneCategories = []string{"upper", "lower", "digit", "other"}
+
+// firstRuneCategory buckets a name by its first rune. Empty names count
+// as other.
+func firstRuneCategory(name string) string {
+	r, _ := utf8.DecodeRuneInString(name)
+	switch {
+	case unicode.IsUpper(r):
+		return "upper"
+	case unicode.IsLower(r):
+		return "lower"
+	case unicode.IsDigit(r):
+		return "digit"
+	default:
+		return "other"
+	}
+}
+
+func genReportFirstRunes(matches []MatchedLine) (string, error) {
+	categoryCount := make(map[string]int)
+	for _, match := range matches {
+		categoryCount[firstRuneCategory(match.Name)]++
+	}
+
+	categories := make([]NameInfo, 0, len(firstRuneCategories))
+	for _, category := range firstRuneCategories {
+		categories = append(categories, NameInfo{Name: category, Count: categoryCount[category]})
+	}
+
+	const firstRunesTemplate = `
+Names by first character:
+{{range .}}{{printf "%10s: %s\n" (formatNumWithCommas .Count) .Name}}{{end}}`
+
+	tmpl, err := template.New("firstrunes").Funcs(funcMap).Parse(firstRunesTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, categories)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
+
+// nameSection is one labeled group of the name counts report. An empty
+// label means the report is not split.
+type nameSection struct {
+	Label string
+	Names []NameInfo
+}
+
+// parseCountBuckets parses the --count-buckets boundaries into distinct
+// values in descending order.
+func parseCountBuckets(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	var bounds []int
+
+	for _, field := range strings.Split(spec, ",") {
+		bound, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || bound < 2 {
+			return nil, fmt.Errorf("invalid count bucket %q, expected a whole number of at least 2", field)
+		}
+		if !seen[bound] {
+			seen[bound] = true
+			bounds = append(bounds, bound)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(bounds)))
+
+	return bounds, nil
+}
+
+// bucketNamesByCount splits names into sections at bounds, which must be
+// in descending order. The last section holds the counts from minCount up
+// to the smallest bound. Sections keep the order of names, so each stays
+// sorted by count, and empty sections are still listed.
+func bucketNamesByCount(names []NameInfo, bounds []int, minCount int) []nameSection {
+	sections := make([]nameSection, 0, len(bounds)+1)
+
+	upper := 0
+	for _, bound := range bounds {
+		label := fmt.Sprintf("%d or more", bound)
+		if upper > 0 {
+			label = countRangeLabel(bound, upper-1)
+		}
+		sections = append(sections, nameSection{Label: label})
+		upper = bound
+	}
+	if lowest := bounds[len(bounds)-1]; lowest > minCount {
+		sections = append(sections, nameSection{Label: countRangeLabel(minCount, lowest-1)})
+		bounds = append(bounds, minCount)
+	}
+
+	for _, info := range names {
+		for i, bound := range bounds {
+			if info.Count >= bound {
+				sections[i].Names = append(sections[i].Names, info)
+				break
+			}
+		}
+	}
+
+	return sections
+}
+
+func countRangeLabel(low int, high int) string {
+	if low == high {
+		return strconv.Itoa(low)
+	}
+	return fmt.Sprintf("%d-%d", low, high)
+}
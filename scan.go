@@ -0,0 +1,397 @@
+package justbe
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const defaultKeyword = "tidbits"
+
+var headingPattern = headingPatternFor([]string{defaultKeyword})
+
+// headingMatcher is a compiled heading pattern along with the indexes of
+// its indent, marker, name and keyword groups, -1 for any it lacks.
+type headingMatcher struct {
+	pattern *regexp.Regexp
+	indent  int
+	marker  int
+	name    int
+	keyword int
+}
+
+func newHeadingMatcher(pattern *regexp.Regexp) headingMatcher {
+	return headingMatcher{
+		pattern: pattern,
+		indent:  pattern.SubexpIndex("indent"),
+		marker:  pattern.SubexpIndex("marker"),
+		name:    pattern.SubexpIndex("name"),
+		keyword: pattern.SubexpIndex("keyword"),
+	}
+}
+
+// compileHeadingMatchers compiles the custom patterns of opts, or without
+// any, the pattern for its keywords. A custom pattern must have a name
+// group and may have indent, marker and keyword groups like the built-in
+// one.
+func compileHeadingMatchers(opts Options) ([]headingMatcher, error) {
+	if len(opts.Patterns) == 0 {
+		pattern := headingPattern
+		if len(opts.Keywords) > 0 {
+			pattern = headingPatternFor(opts.Keywords)
+		}
+		return []headingMatcher{newHeadingMatcher(pattern)}, nil
+	}
+
+	matchers := make([]headingMatcher, 0, len(opts.Patterns))
+	for _, expr := range opts.Patterns {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", expr, err)
+		}
+
+		matcher := newHeadingMatcher(pattern)
+		if matcher.name < 0 {
+			return nil, fmt.Errorf("invalid pattern %q: no (?P<name>...) group", expr)
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	return matchers, nil
+}
+
+// group returns the text of group in a match located by loc, or "" when
+// the pattern has no such group or it did not participate.
+func group(line string, loc []int, group int) string {
+	if group < 0 || loc[2*group] < 0 {
+		return ""
+	}
+	return line[loc[2*group]:loc[2*group+1]]
+}
+
+// headingPatternFor builds the heading pattern for headings ending in any
+// of keywords, matched case-insensitively.
+func headingPatternFor(keywords []string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)^(?P<indent>[ \t]*)(?P<marker>\*+)\s+(?P<name>.*)\s+(?P<keyword>` + keywordAlternation(keywords) + `)$`)
+}
+
+// keywordAlternation joins keywords into a regexp alternation, falling
+// back to the default keyword when none are given.
+func keywordAlternation(keywords []string) string {
+	var quoted []string
+	for _, keyword := range keywords {
+		if keyword != "" {
+			quoted = append(quoted, regexp.QuoteMeta(keyword))
+		}
+	}
+
+	if len(quoted) == 0 {
+		return defaultKeyword
+	}
+
+	return strings.Join(quoted, "|")
+}
+
+// canonicalKeyword returns the configured keyword that found matches,
+// ignoring case, so matches carry the keyword as it was configured
+// rather than as it was written in the file.
+func canonicalKeyword(found string, keywords []string) string {
+	for _, keyword := range keywords {
+		if strings.EqualFold(keyword, found) {
+			return keyword
+		}
+	}
+	return found
+}
+
+const utf8BOM = "\uFEFF"
+
+// Options control how ScanReader finds matches.
+type Options struct {
+	// LineStart and LineEnd restrict matching to an inclusive range of
+	// line numbers. Zero leaves that end of the range open.
+	LineStart int
+	LineEnd   int
+
+	// LineEnding selects how lines are split: "lf" (the default) splits
+	// on LF and CRLF, "cr" also splits on a lone CR and "auto" picks "cr"
+	// when the start of the input has lone CRs.
+	LineEnding string
+
+	// JoinContinuations joins a line ending in ContinuationMarker with the
+	// line after it, with the marker removed, before matching. The match
+	// reports the line number of the first physical line.
+	JoinContinuations  bool
+	ContinuationMarker string
+
+	// IndentFrom selects how IndentLevel is computed: "marker" (the
+	// default) counts the asterisks and "whitespace" also adds one level
+	// per tab and per two spaces before them.
+	IndentFrom string
+
+	// Keywords are the words a heading may end with. Empty means the
+	// default, "tidbits".
+	Keywords []string
+
+	// Patterns replace the built-in heading pattern and Keywords. A line
+	// is matched by the first pattern that matches it.
+	Patterns []string
+
+	// UnescapeNewlines turns each literal backslash-n in the input into a
+	// real newline before splitting lines, so line numbers count the
+	// unescaped lines.
+	UnescapeNewlines bool
+
+	// SectionPattern, when set, is a regexp searched for in each name.
+	// Where it matches, the first capture group, or the whole match
+	// without one, becomes the section and the match is cut from the name.
+	SectionPattern string
+
+	// DatePattern, when set, is a regexp searched for in each name before
+	// SectionPattern. What it captures, in the same way, becomes the date
+	// and is cut from the name.
+	DatePattern string
+
+	// OnMatch, when set, is called with each match as it is found.
+	OnMatch func(MatchedLine)
+
+	// Explain, when set, receives a line for each match naming the
+	// pattern that matched and its groups. With ExplainNearMisses it also
+	// receives lines that look like headings but matched no pattern.
+	Explain           io.Writer
+	ExplainNearMisses bool
+
+	// MaxMatches stops the scan once this many matches are found. Zero
+	// means no limit.
+	MaxMatches int
+}
+
+// scanOptions builds the scan options from the command line flags.
+func scanOptions() Options {
+	return Options{
+		LineStart:  opts.LineStart,
+		LineEnd:    opts.LineEnd,
+		LineEnding: opts.LineEnding,
+
+		JoinContinuations:  opts.JoinContinuations,
+		ContinuationMarker: opts.ContinuationMarker,
+
+		IndentFrom: opts.IndentFrom,
+
+		Keywords: opts.Keywords,
+		Patterns: opts.Patterns,
+
+		UnescapeNewlines: opts.UnescapeNewlines,
+
+		SectionPattern: opts.SectionPattern,
+		DatePattern:    opts.DatePattern,
+	}
+}
+
+// ScanReader returns the heading matches read from r. The path is only
+// recorded on the returned matches and used in errors, r is never reopened.
+func ScanReader(r io.Reader, path string, opts Options) ([]MatchedLine, error) {
+	var matches []MatchedLine
+
+	matchers, err := compileHeadingMatchers(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var loosePattern *regexp.Regexp
+	if opts.Explain != nil && opts.ExplainNearMisses {
+		loosePattern = looseHeadingPatternFor(opts.Keywords)
+	}
+
+	var sectionPattern *regexp.Regexp
+	if opts.SectionPattern != "" {
+		sectionPattern, err = regexp.Compile(opts.SectionPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid section pattern %q: %v", opts.SectionPattern, err)
+		}
+	}
+
+	var datePattern *regexp.Regexp
+	if opts.DatePattern != "" {
+		datePattern, err = regexp.Compile(opts.DatePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date pattern %q: %v", opts.DatePattern, err)
+		}
+	}
+
+	err = eachLine(r, path, opts, func(startLine int, line string) bool {
+		matcher, loc := findHeading(matchers, line)
+		if loc == nil {
+			if loosePattern != nil && loosePattern.MatchString(line) {
+				fmt.Fprintf(opts.Explain, "%s:%d: near miss, no pattern matched %q\n", path, startLine, line)
+			}
+			return true
+		}
+
+		indentLevel := 1
+		if matcher.marker >= 0 {
+			indentLevel = len(group(line, loc, matcher.marker))
+		}
+		if opts.IndentFrom == "whitespace" {
+			indentLevel += whitespaceIndent(group(line, loc, matcher.indent))
+		}
+		name := strings.TrimSpace(group(line, loc, matcher.name))
+		column := utf8.RuneCountInString(line[:max(loc[2*matcher.name], 0)]) + 1
+
+		date := ""
+		if datePattern != nil {
+			date, name = splitCapture(datePattern, name)
+		}
+		section := ""
+		if sectionPattern != nil {
+			section, name = splitCapture(sectionPattern, name)
+		}
+		matchedLine := MatchedLine{
+			FilePath:    path,
+			LineNumber:  startLine,
+			Name:        name,
+			Section:     section,
+			Date:        date,
+			IndentLevel: indentLevel,
+			Column:      column,
+			Keyword:     canonicalKeyword(group(line, loc, matcher.keyword), opts.Keywords),
+			RawLine:     line,
+		}
+
+		if opts.Explain != nil {
+			explainMatch(opts.Explain, matchedLine, matchers, matcher, loc)
+		}
+
+		matches = append(matches, matchedLine)
+		if opts.OnMatch != nil {
+			opts.OnMatch(matchedLine)
+		}
+		return opts.MaxMatches <= 0 || len(matches) < opts.MaxMatches
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// eachLine calls fn with each line of r within the line range of opts and
+// the number of the line it starts on, until fn returns false. Newlines
+// are unescaped, a leading byte order mark is dropped and continued lines
+// are joined first, so every check of the lines of a file sees the same
+// lines under the same numbers.
+func eachLine(r io.Reader, path string, opts Options, fn func(lineNumber int, line string) bool) error {
+	if opts.UnescapeNewlines {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return &FileError{Op: "read", Path: path, Err: err}
+		}
+		r = bytes.NewReader(bytes.ReplaceAll(data, []byte(`\n`), []byte("\n")))
+	}
+
+	scanner := newLineScanner(r, opts.LineEnding)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+
+		// a UTF-8 byte order mark is never content, and left in place it
+		// would keep an anchored pattern from matching the first line
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
+
+		startLine := lineNumber
+		if opts.JoinContinuations && opts.ContinuationMarker != "" {
+			for strings.HasSuffix(line, opts.ContinuationMarker) && scanner.Scan() {
+				lineNumber++
+				line = strings.TrimSuffix(line, opts.ContinuationMarker) + scanner.Text()
+			}
+		}
+
+		if startLine < opts.LineStart {
+			continue
+		}
+		if opts.LineEnd > 0 && startLine > opts.LineEnd {
+			break
+		}
+
+		if !fn(startLine, line) {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &FileError{Op: "read", Path: path, Err: err}
+	}
+
+	return nil
+}
+
+// whitespaceIndent counts the indent levels in the whitespace before a
+// heading marker: one per tab and one per two spaces, with an odd
+// trailing space ignored.
+func whitespaceIndent(indent string) int {
+	tabs := strings.Count(indent, "\t")
+	spaces := strings.Count(indent, " ")
+	return tabs + spaces/2
+}
+
+// splitCapture finds pattern in name and returns its first capture group,
+// or the whole match without one, along with the name with the match
+// removed. When pattern does not match, the capture is empty and the name
+// is returned unchanged.
+func splitCapture(pattern *regexp.Regexp, name string) (string, string) {
+	loc := pattern.FindStringSubmatchIndex(name)
+	if loc == nil {
+		return "", name
+	}
+
+	section := name[loc[0]:loc[1]]
+	if len(loc) >= 4 && loc[2] >= 0 {
+		section = name[loc[2]:loc[3]]
+	}
+
+	rest := strings.Join(strings.Fields(name[:loc[0]]+" "+name[loc[1]:]), " ")
+	return strings.TrimSpace(section), rest
+}
+
+// findHeading returns the first matcher whose pattern matches line along
+// with the match location, or a nil location when none does.
+func findHeading(matchers []headingMatcher, line string) (headingMatcher, []int) {
+	for _, matcher := range matchers {
+		if loc := matcher.pattern.FindStringSubmatchIndex(line); loc != nil {
+			return matcher, loc
+		}
+	}
+	return headingMatcher{}, nil
+}
+
+// explainMatch writes which of matchers matched and what each of its
+// groups captured, numbering patterns from 1 in --pattern order.
+func explainMatch(w io.Writer, match MatchedLine, matchers []headingMatcher, matcher headingMatcher, loc []int) {
+	index := 0
+	for i := range matchers {
+		if matchers[i].pattern == matcher.pattern {
+			index = i + 1
+		}
+	}
+
+	var groups []string
+	for i, groupName := range matcher.pattern.SubexpNames() {
+		if i == 0 {
+			continue
+		}
+		if groupName == "" {
+			groupName = strconv.Itoa(i)
+		}
+		groups = append(groups, fmt.Sprintf("%s=%q", groupName, group(match.RawLine, loc, i)))
+	}
+
+	fmt.Fprintf(w, "%s:%d: pattern %d matched %s\n", match.FilePath, match.LineNumber, index, strings.Join(groups, " "))
+}
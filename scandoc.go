@@ -0,0 +1,127 @@
+package justbe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// scanDocument is the JSON form of a scan. It is written by --save-scan and
+// read back as the baseline for --diff. --format json also fills in the
+// reports selected on the command line that have a JSON form.
+type scanDocument struct {
+	Matches []MatchedLine   `json:"matches"`
+	Names   []nameCountJSON `json:"names,omitempty"`
+	Stats   *statsJSON      `json:"stats,omitempty"`
+}
+
+type nameCountJSON struct {
+	Name   string   `json:"name"`
+	Count  int      `json:"count"`
+	Places []string `json:"places"`
+}
+
+func encodeScanDocument(matches []MatchedLine) ([]byte, error) {
+	if matches == nil {
+		matches = []MatchedLine{}
+	}
+
+	data, err := json.MarshalIndent(scanDocument{Matches: matches}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding scan: %v", err)
+	}
+
+	return data, nil
+}
+
+// encodeReportDocument encodes the scan for --format json, holding the
+// matches plus the name counts and stats when those reports are selected,
+// so the document still reads back as a scan. The match paths are kept as
+// scanned rather than formatted, so the matches of a document given to
+// --merge-across-runs or --diff line up with those of the current scan.
+func encodeReportDocument(matches []MatchedLine, paths []string) ([]byte, error) {
+	doc := scanDocument{Matches: matches}
+	if doc.Matches == nil {
+		doc.Matches = []MatchedLine{}
+	}
+
+	if opts.ReportNameCounts {
+		names, _ := duplicateNameInfos(matches)
+		doc.Names = make([]nameCountJSON, 0, len(names))
+		for _, info := range names {
+			doc.Names = append(doc.Names, nameCountJSON{Name: info.Name, Count: info.Count, Places: info.Places})
+		}
+	}
+
+	if opts.ReportStats {
+		stats := buildStatsJSON(buildStatsReport(matches, paths))
+		doc.Stats = &stats
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding scan: %v", err)
+	}
+
+	return data, nil
+}
+
+func writeScanDocument(path string, matches []MatchedLine) error {
+	data, err := encodeScanDocument(matches)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing scan %s: %v", path, err)
+	}
+
+	return nil
+}
+
+func readScanDocument(path string) ([]MatchedLine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading scan %s: %v", path, err)
+	}
+
+	var doc scanDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error decoding scan %s: %v", path, err)
+	}
+
+	return doc.Matches, nil
+}
+
+// mergeScans adds to matches the matches of earlier scans saved at paths.
+// A match at a file and line already present is a repeat of the same
+// heading, so it is dropped and the copy from the current scan is kept.
+func mergeScans(matches []MatchedLine, paths []string) ([]MatchedLine, error) {
+	type position struct {
+		file string
+		line int
+	}
+
+	seen := make(map[position]bool, len(matches))
+	for _, match := range matches {
+		seen[position{match.FilePath, match.LineNumber}] = true
+	}
+
+	for _, path := range paths {
+		earlier, err := readScanDocument(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range earlier {
+			key := position{match.FilePath, match.LineNumber}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			matches = append(matches, match)
+		}
+	}
+
+	return matches, nil
+}
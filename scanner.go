@@ -0,0 +1,122 @@
+package justbe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Scanner finds the headings in a set of files and readers for programs
+// using justbe as a library. Unlike the command, it reads no flags or
+// other package state, so several scanners with different settings can be
+// used side by side.
+type Scanner struct {
+	options Options
+	paths   []string
+	readers []namedReader
+}
+
+type namedReader struct {
+	name string
+	r    io.Reader
+}
+
+// ScannerOption configures a Scanner created by NewScanner.
+type ScannerOption func(*Scanner)
+
+// NewScanner returns a Scanner configured by options, which are applied
+// in order.
+func NewScanner(options ...ScannerOption) *Scanner {
+	s := &Scanner{}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// WithOptions replaces the scan options set so far, including patterns
+// and keywords.
+func WithOptions(options Options) ScannerOption {
+	return func(s *Scanner) {
+		s.options = options
+	}
+}
+
+// WithPattern adds a heading pattern as --pattern does. Once any pattern
+// is given, only the given patterns are matched.
+func WithPattern(pattern string) ScannerOption {
+	return func(s *Scanner) {
+		s.options.Patterns = append(s.options.Patterns, pattern)
+	}
+}
+
+// WithKeywords adds words a heading may end with as --keyword does.
+// Without any, headings must end with "tidbits".
+func WithKeywords(keywords ...string) ScannerOption {
+	return func(s *Scanner) {
+		s.options.Keywords = append(s.options.Keywords, keywords...)
+	}
+}
+
+// WithPaths adds files to scan. Paths are used as given, without the
+// tilde, brace and glob expansion of --path.
+func WithPaths(paths ...string) ScannerOption {
+	return func(s *Scanner) {
+		s.paths = append(s.paths, paths...)
+	}
+}
+
+// WithReader adds r to the inputs, scanned after the paths. Its matches
+// report name as their file.
+func WithReader(name string, r io.Reader) ScannerOption {
+	return func(s *Scanner) {
+		s.readers = append(s.readers, namedReader{name: name, r: r})
+	}
+}
+
+// Scan returns the matches of every path and then every reader, each in
+// line order. It stops at the first input that fails or when ctx is done.
+func (s *Scanner) Scan(ctx context.Context) ([]MatchedLine, error) {
+	if _, err := compileHeadingMatchers(s.options); err != nil {
+		return nil, err
+	}
+
+	var matches []MatchedLine
+
+	for _, path := range s.paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		fileMatches, err := s.scanPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("error processing file %s: %w", path, err)
+		}
+		matches = append(matches, fileMatches...)
+	}
+
+	for _, reader := range s.readers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		readerMatches, err := ScanReader(reader.r, reader.name, s.options)
+		if err != nil {
+			return nil, fmt.Errorf("error processing %s: %w", reader.name, err)
+		}
+		matches = append(matches, readerMatches...)
+	}
+
+	return matches, nil
+}
+
+func (s *Scanner) scanPath(path string) ([]MatchedLine, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, &FileError{Op: "open", Path: path, Err: err}
+	}
+	defer file.Close()
+
+	return ScanReader(file, path, s.options)
+}
@@ -0,0 +1,80 @@
+package justbe
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScannerWithReader(t *testing.T) {
+	scanner := NewScanner(
+		WithReader("first", strings.NewReader("* One tidbits\n")),
+		WithReader("second", strings.NewReader("intro\n** Two tidbits\n")),
+	)
+
+	matches, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 2 {
+		t.Fatalf("got %d matches, want 2", len(matches))
+	}
+	if got := matches[0]; got.FilePath != "first" || got.LineNumber != 1 || got.Name != "One" {
+		t.Errorf("first match = %+v", got)
+	}
+	if got := matches[1]; got.FilePath != "second" || got.LineNumber != 2 || got.Name != "Two" || got.IndentLevel != 2 {
+		t.Errorf("second match = %+v", got)
+	}
+}
+
+func TestScannerWithPatternOverridesKeywords(t *testing.T) {
+	input := "* Standup tidbits\nTODO: write tests\n* Retro notes\n"
+
+	scanner := NewScanner(
+		WithKeywords("notes"),
+		WithPattern(`^TODO: (?P<name>.+)$`),
+		WithReader("todo.txt", strings.NewReader(input)),
+	)
+
+	matches, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 1 || matches[0].Name != "write tests" || matches[0].LineNumber != 2 {
+		t.Errorf("matches = %+v, want only the TODO line", matches)
+	}
+}
+
+func TestScannerContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	scanner := NewScanner(WithReader("notes", strings.NewReader("* One tidbits\n")))
+
+	matches, err := scanner.Scan(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if matches != nil {
+		t.Errorf("matches = %+v, want none", matches)
+	}
+}
+
+func TestScannerFileError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.org")
+
+	_, err := NewScanner(WithPaths(path)).Scan(context.Background())
+
+	var fileErr *FileError
+	if !errors.As(err, &fileErr) {
+		t.Fatalf("err = %v, want a *FileError", err)
+	}
+	if fileErr.Op != "open" || fileErr.Path != path || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("err = %+v, want an open error for %s", fileErr, path)
+	}
+}
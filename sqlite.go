@@ -0,0 +1,54 @@
+//go:build sqlite
+
+package justbe
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// writeSQLite stores matches in the matches table of the database at path,
+// replacing whatever an earlier run put there.
+func writeSQLite(path string, matches []MatchedLine) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("error opening database %s: %v", path, err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	statements := []string{
+		`DROP TABLE IF EXISTS matches`,
+		`CREATE TABLE matches (file TEXT, line INT, name TEXT, indent INT)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("error creating table: %v", err)
+		}
+	}
+
+	insert, err := tx.Prepare(`INSERT INTO matches (file, line, name, indent) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("error preparing insert: %v", err)
+	}
+	defer insert.Close()
+
+	for _, match := range matches {
+		if _, err := insert.Exec(match.FilePath, match.LineNumber, match.Name, match.IndentLevel); err != nil {
+			return fmt.Errorf("error inserting match %s:%d: %v", match.FilePath, match.LineNumber, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing matches: %v", err)
+	}
+
+	return nil
+}
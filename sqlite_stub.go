@@ -0,0 +1,9 @@
+//go:build !sqlite
+
+package justbe
+
+import "fmt"
+
+func writeSQLite(path string, matches []MatchedLine) error {
+	return fmt.Errorf("sqlite export is not available, rebuild with -tags sqlite")
+}
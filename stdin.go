@@ -0,0 +1,51 @@
+package justbe
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// stdinPath stands for the content piped to stdin with --stdin or a path
+// of "-". It is what matches from stdin report as their file.
+const stdinPath = "(stdin)"
+
+// stdinContent holds stdin, read once up front since every report that
+// rereads a file reads it again through openInput.
+var stdinContent []byte
+
+// splitStdinPath removes each "-" from paths and reports whether there
+// was one.
+func splitStdinPath(paths []string) ([]string, bool) {
+	if !slices.Contains(paths, "-") {
+		return paths, false
+	}
+
+	var files []string
+	for _, path := range paths {
+		if path != "-" {
+			files = append(files, path)
+		}
+	}
+
+	return files, true
+}
+
+func readStdinContent(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading stdin: %v", err)
+	}
+	stdinContent = data
+	return nil
+}
+
+// openInput opens path for reading, or the stdin content for stdinPath.
+func openInput(path string) (io.ReadCloser, error) {
+	if path == stdinPath {
+		return io.NopCloser(bytes.NewReader(stdinContent)), nil
+	}
+	return os.Open(path)
+}
@@ -0,0 +1,68 @@
+package justbe
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	svgWidth       = 640
+	svgLabelWidth  = 200
+	svgBarWidth    = 360
+	svgRowHeight   = 24
+	svgBarHeight   = 18
+	svgPadding     = 10
+	svgLabelRunes  = 30
+	svgFontSize    = 12
+	svgFontFamily  = "sans-serif"
+	svgBarColor    = "#4682b4"
+	svgEmptyHeight = 40
+)
+
+var svgEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+// genReportSVG renders a horizontal bar chart of the top most frequent
+// names as a standalone SVG document, with bar widths scaled to the
+// highest count. Names longer than svgLabelRunes are cut in the label but
+// kept whole in the bar's tooltip.
+func genReportSVG(matches []MatchedLine, top int) string {
+	names := collectNameInfos(matches)
+	if top > 0 && len(names) > top {
+		names = names[:top]
+	}
+
+	height := svgEmptyHeight
+	if len(names) > 0 {
+		height = 2*svgPadding + len(names)*svgRowHeight
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"%s\" font-size=\"%d\">\n",
+		svgWidth, height, svgWidth, height, svgFontFamily, svgFontSize)
+
+	if len(names) == 0 {
+		fmt.Fprintf(&b, "  <text x=\"%d\" y=\"%d\">no matches</text>\n", svgPadding, height/2)
+	}
+
+	maxCount := 0
+	if len(names) > 0 {
+		maxCount = names[0].Count
+	}
+
+	for i, info := range names {
+		y := svgPadding + i*svgRowHeight
+		textY := y + svgBarHeight/2 + svgFontSize/3
+		width := max(info.Count*svgBarWidth/maxCount, 1)
+		name := svgEscaper.Replace(info.Name)
+
+		fmt.Fprintf(&b, "  <text x=\"%d\" y=\"%d\" text-anchor=\"end\">%s</text>\n",
+			svgLabelWidth-svgPadding, textY, svgEscaper.Replace(truncateRunes(info.Name, svgLabelRunes)))
+		fmt.Fprintf(&b, "  <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"><title>%s: %d</title></rect>\n",
+			svgLabelWidth, y, width, svgBarHeight, svgBarColor, name, info.Count)
+		fmt.Fprintf(&b, "  <text x=\"%d\" y=\"%d\">%d</text>\n", svgLabelWidth+width+svgPadding/2, textY, info.Count)
+	}
+
+	b.WriteString("</svg>\n")
+
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package justbe
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// defaultTemplates holds the built-in layouts of the matches, stats and
+// name counts reports, one name.tmpl file each.
+//
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// reportTemplate returns the template text for the named report: the file
+// given with its --template-* flag, else name.tmpl in --template-dir when
+// that exists, else the built-in template.
+func reportTemplate(name string) (string, error) {
+	path := map[string]string{
+		"matches": opts.TemplateMatches,
+		"stats":   opts.TemplateStats,
+		"names":   opts.TemplateNames,
+	}[name]
+
+	if path == "" && opts.TemplateDir != "" {
+		candidate := filepath.Join(opts.TemplateDir, name+".tmpl")
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("error reading template %s: %v", candidate, err)
+		}
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading template %s: %v", path, err)
+		}
+		return string(data), nil
+	}
+
+	data, err := defaultTemplates.ReadFile("templates/" + name + ".tmpl")
+	if err != nil {
+		return "", fmt.Errorf("error reading built-in template %s: %v", name, err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,41 @@
+package justbe
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// versionString describes the running build from the module version and,
+// when the binary was built from a checkout, the VCS revision.
+func versionString() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "justbe unknown version"
+	}
+
+	version := info.Main.Version
+	if version == "" {
+		version = "(devel)"
+	}
+
+	var details []string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			details = append(details, "revision "+setting.Value)
+		case "vcs.time":
+			details = append(details, "committed "+setting.Value)
+		case "vcs.modified":
+			if setting.Value == "true" {
+				details = append(details, "modified")
+			}
+		}
+	}
+
+	if len(details) == 0 {
+		return fmt.Sprintf("justbe %s %s", version, info.GoVersion)
+	}
+
+	return fmt.Sprintf("justbe %s %s (%s)", version, info.GoVersion, strings.Join(details, ", "))
+}
@@ -0,0 +1,134 @@
+package justbe
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// expandDirectories replaces each directory in paths with the text files
+// found by walking it, in lexical order, leaving other paths as they are.
+// Hidden files and directories, those whose names start with a dot, are
+// skipped along with whatever rules ignores or --exclude matches, so an
+// ignored or excluded directory is never entered. With dirIgnoreFile set,
+// the ignore file of that name in each directory entered also applies
+// below it, its patterns relative to it and checked after those of the
+// directories above, so they can re-include what those exclude or exclude
+// what they leave. maxDepth limits how
+// deep the walk goes, 1 takes only the files directly in the directory
+// and 0 has no limit. With skipUnreadable, entries that cannot be read
+// are recorded and skipped instead of failing the walk.
+func expandDirectories(paths []string, rules *ignoreRules, dirIgnoreFile string, maxDepth int, skipUnreadable bool) ([]string, error) {
+	var expanded []string
+
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil || !info.IsDir() {
+			// a missing path is reported by the text file check later
+			expanded = append(expanded, root)
+			continue
+		}
+
+		// the rules given with --ignore-file stay at the bottom of the
+		// stack, the ignore files of the directories entered go on top
+		stack := ignoreStack{rules}
+		enter := func(dir string) error {
+			if dirIgnoreFile == "" {
+				return nil
+			}
+			dirRules, err := loadIgnoreFile(filepath.Join(dir, dirIgnoreFile))
+			if err != nil {
+				return err
+			}
+			if dirRules != nil {
+				stack = append(stack, dirRules)
+			}
+			return nil
+		}
+		if err := enter(root); err != nil {
+			return nil, err
+		}
+
+		err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if !skipUnreadable || path == root {
+					return err
+				}
+				recordUnreadable(path, err)
+				if entry != nil && entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if path == root {
+				return nil
+			}
+
+			// the walk is depth first, so the rules of the directories it
+			// has left are those on top that do not hold path
+			for len(stack) > 1 && !stack[len(stack)-1].contains(path) {
+				stack = stack[:len(stack)-1]
+			}
+
+			if strings.HasPrefix(entry.Name(), ".") {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			excluded, err := isExcluded(path)
+			if err != nil {
+				return err
+			}
+			if excluded {
+				slog.Debug("skipping excluded path", "path", path)
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			relPath, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			depth := len(strings.Split(filepath.ToSlash(relPath), "/"))
+			if entry.IsDir() {
+				if stack.ignores(path, true) || (maxDepth > 0 && depth >= maxDepth) {
+					slog.Debug("skipping directory", "path", path)
+					return filepath.SkipDir
+				}
+				return enter(path)
+			}
+
+			if !entry.Type().IsRegular() || stack.ignores(path, false) {
+				return nil
+			}
+
+			if err := checkTextFile(path); err != nil {
+				if errors.Is(err, ErrNotTextFile) {
+					slog.Debug("skipping file that is not text", "path", path)
+					return nil
+				}
+				if skipUnreadable {
+					recordUnreadable(path, err)
+					return nil
+				}
+				return err
+			}
+
+			expanded = append(expanded, path)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error walking directory %s: %w", root, err)
+		}
+	}
+
+	return expanded, nil
+}
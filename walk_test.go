@@ -0,0 +1,57 @@
+package justbe
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandDirectoriesNestedIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".justbeignore":            "*.log\narchive/\n",
+		"notes.txt":                "notes\n",
+		"debug.log":                "debug\n",
+		"secret.txt":               "secret\n",
+		"archive/old.txt":          "old\n",
+		"sub/.justbeignore":        "!keep.log\nsecret.txt\n",
+		"sub/keep.log":             "keep\n",
+		"sub/drop.log":             "drop\n",
+		"sub/secret.txt":           "secret\n",
+		"sub/deeper/.justbeignore": "!secret.txt\n",
+		"sub/deeper/secret.txt":    "secret\n",
+		"sub/deeper/trace.log":     "trace\n",
+		"sibling/keep.log":         "keep\n",
+		"sibling/secret.txt":       "secret\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	detectedMimetypes = make(map[string]string)
+	got, err := expandDirectories([]string{root}, nil, defaultIgnoreFile, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	for _, name := range []string{
+		"notes.txt",
+		"secret.txt",
+		"sibling/secret.txt",
+		"sub/deeper/secret.txt",
+		"sub/keep.log",
+	} {
+		want = append(want, filepath.Join(root, filepath.FromSlash(name)))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandDirectories() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package justbe
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long changes must settle before the reports are
+// regenerated, since an editor saving a file often causes several events.
+const watchDebounce = 200 * time.Millisecond
+
+// watchCache keeps matches between the runs of --watch when there is no
+// --cache file, so only files that changed are scanned again.
+var watchCache *scanCache
+
+// scannedPaths are the files the last run scanned, which --watch watches.
+var scannedPaths []string
+
+// watch runs the scan and then runs it again whenever a scanned file
+// changes or a file is created next to one, until it fails to watch. A
+// failing rerun is logged and watching goes on, so a file saved half way
+// through an edit does not end the session.
+func watch(paths []string) error {
+	if _, readsStdin := splitStdinPath(paths); readsStdin || opts.Stdin || opts.FilesFromStdin {
+		return errors.New("--watch cannot be combined with reading stdin")
+	}
+	if opts.Replace || opts.Interactive {
+		return errors.New("--watch cannot be combined with --replace or --interactive")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error starting watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	watchCache = &scanCache{Entries: make(map[string]cacheEntry)}
+
+	if err := rerunForWatch(paths); err != nil {
+		return err
+	}
+
+	watched, err := watchScannedPaths(watcher)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if watched[event.Name] || event.Has(fsnotify.Create) {
+				slog.Debug("watched file changed", "path", event.Name, "op", event.Op.String())
+				timer.Reset(watchDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("error watching files", "error", err)
+
+		case <-timer.C:
+			if err := rerunForWatch(paths); err != nil {
+				slog.Error("run failed", "error", err)
+				continue
+			}
+
+			watched, err = watchScannedPaths(watcher)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// rerunForWatch clears the terminal, when stdout is one, so each run
+// replaces the reports of the one before, and runs the scan.
+func rerunForWatch(paths []string) error {
+	if isTerminal(os.Stdout) {
+		fmt.Print("\x1b[H\x1b[2J")
+	}
+	return runWithTimeout(paths)
+}
+
+// watchScannedPaths watches the directories of the scanned files, which
+// also catches editors that save by replacing a file, and returns the
+// scanned files by absolute path since events are named that way.
+func watchScannedPaths(watcher *fsnotify.Watcher) (map[string]bool, error) {
+	watched := make(map[string]bool, len(scannedPaths))
+
+	for _, path := range scannedPaths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving path %s: %v", path, err)
+		}
+		watched[absPath] = true
+
+		// adding a directory that is already watched does nothing
+		if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+			return nil, fmt.Errorf("error watching %s: %v", filepath.Dir(absPath), err)
+		}
+	}
+
+	return watched, nil
+}
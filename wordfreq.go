@@ -0,0 +1,142 @@
+package justbe
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+var defaultStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from",
+	"has", "have", "he", "her", "his", "i", "if", "in", "into", "is", "it",
+	"its", "me", "my", "no", "not", "of", "on", "or", "our", "she", "so",
+	"that", "the", "their", "them", "then", "there", "these", "they", "this",
+	"to", "too", "us", "was", "we", "were", "what", "when", "which", "who",
+	"will", "with", "you", "your",
+}
+
+func loadStopwords(path string) (map[string]bool, error) {
+	stopwords := make(map[string]bool, len(defaultStopwords))
+	for _, word := range defaultStopwords {
+		stopwords[word] = true
+	}
+
+	if path == "" {
+		return stopwords, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening stopwords file %s: %v", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word != "" {
+			stopwords[word] = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading stopwords file %s: %v", path, err)
+	}
+
+	return stopwords, nil
+}
+
+func countWordsInFile(path string, stopwords map[string]bool, counts map[string]int) error {
+	file, err := openInput(path)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %v", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Split(bufio.ScanWords)
+
+	isSeparator := func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}
+
+	for scanner.Scan() {
+		for _, word := range strings.FieldsFunc(scanner.Text(), isSeparator) {
+			word = strings.ToLower(strings.Trim(word, "'"))
+			if word == "" || stopwords[word] {
+				continue
+			}
+			counts[word]++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file %s: %v", path, err)
+	}
+
+	return nil
+}
+
+func genReportWordFreq(paths []string) (string, error) {
+	type WordInfo struct {
+		Word  string
+		Count int
+	}
+
+	stopwords, err := loadStopwords(opts.Stopwords)
+	if err != nil {
+		return "", err
+	}
+
+	counts := make(map[string]int)
+	for _, path := range paths {
+		if err := countWordsInFile(path, stopwords, counts); err != nil {
+			return "", err
+		}
+	}
+
+	words := make([]WordInfo, 0, len(counts))
+	for word, count := range counts {
+		words = append(words, WordInfo{Word: word, Count: count})
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].Count != words[j].Count {
+			return words[i].Count > words[j].Count
+		}
+		return words[i].Word < words[j].Word
+	})
+
+	if opts.WordFreqTop > 0 && len(words) > opts.WordFreqTop {
+		words = words[:opts.WordFreqTop]
+	}
+
+	wordsTemplate := `
+Word frequencies, distinct words: {{ formatNumWithCommas .TotalWords }}
+{{range .Words}}{{printf "%10s: %s\n" (formatNumWithCommas .Count) .Word}}{{end}}`
+
+	tmpl, err := template.New("words").Funcs(funcMap).Parse(wordsTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error creating template: %v", err)
+	}
+
+	wordsData := struct {
+		Words      []WordInfo
+		TotalWords int
+	}{
+		Words:      words,
+		TotalWords: len(counts),
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, wordsData)
+	if err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return b.String(), nil
+}
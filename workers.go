@@ -0,0 +1,97 @@
+package justbe
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// scanFiles returns the matches of paths in path order, scanning up to
+// workers files at once. With a match limit, a sequential scan stops at the
+// file that reaches it, while workers each scan up to the whole limit and
+// the combined matches are cut to it afterwards, so both report the same
+// matches. Files are handed out in path order and no more are once the
+// files handed out have the limit between them, as the matches kept can
+// only come from those.
+func scanFiles(ctx context.Context, paths []string, cache *scanCache, workers int) ([]MatchedLine, error) {
+	if workers <= 1 {
+		return scanFilesSequentially(ctx, paths, cache)
+	}
+
+	results := make([][]MatchedLine, len(paths))
+	errs := make([]error, len(paths))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	var found atomic.Int64
+
+	for n := 0; n < min(workers, len(paths)); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = scanFile(paths[i], cache, opts.MaxMatches, &results[i])
+				found.Add(int64(len(results[i])))
+			}
+		}()
+	}
+
+	for i := range paths {
+		if opts.MaxMatches > 0 && found.Load() >= int64(opts.MaxMatches) {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var matches []MatchedLine
+	for i, path := range paths {
+		if errs[i] != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			return nil, fmt.Errorf("error processing file %s: %w", path, errs[i])
+		}
+		matches = append(matches, results[i]...)
+
+		if opts.MaxMatches > 0 && len(matches) >= opts.MaxMatches {
+			slog.Warn("stopped scanning at the match limit, results may be truncated", "max_matches", opts.MaxMatches)
+			return matches[:opts.MaxMatches], nil
+		}
+	}
+
+	return matches, nil
+}
+
+func scanFilesSequentially(ctx context.Context, paths []string, cache *scanCache) ([]MatchedLine, error) {
+	var matches []MatchedLine
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		limit := 0
+		if opts.MaxMatches > 0 {
+			limit = opts.MaxMatches - len(matches)
+		}
+
+		if err := scanFile(path, cache, limit, &matches); err != nil {
+			return nil, fmt.Errorf("error processing file %s: %w", path, err)
+		}
+
+		if opts.MaxMatches > 0 && len(matches) >= opts.MaxMatches {
+			slog.Warn("stopped scanning at the match limit, results may be truncated", "max_matches", opts.MaxMatches)
+			break
+		}
+	}
+
+	return matches, nil
+}
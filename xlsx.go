@@ -0,0 +1,86 @@
+//go:build xlsx
+
+package justbe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeXLSX writes a workbook with a Summary sheet of name counts followed
+// by one sheet per input file listing its matches.
+func writeXLSX(path string, paths []string, matches []MatchedLine) error {
+	book := excelize.NewFile()
+	defer book.Close()
+
+	used := map[string]bool{"summary": true}
+	if err := book.SetSheetName("Sheet1", "Summary"); err != nil {
+		return fmt.Errorf("error creating summary sheet: %v", err)
+	}
+
+	rows := [][]any{{"Name", "Count"}}
+	for _, info := range collectNameInfos(matches) {
+		rows = append(rows, []any{info.Name, info.Count})
+	}
+	if err := writeSheetRows(book, "Summary", rows); err != nil {
+		return err
+	}
+
+	byFile := make(map[string][]MatchedLine)
+	for _, match := range matches {
+		byFile[match.FilePath] = append(byFile[match.FilePath], match)
+	}
+
+	seen := make(map[string]bool)
+	for _, file := range paths {
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		sheet := sheetName(filepath.Base(file), used)
+		if _, err := book.NewSheet(sheet); err != nil {
+			return fmt.Errorf("error creating sheet for %s: %v", file, err)
+		}
+
+		rows := [][]any{{"Name", "Line", "Indent"}}
+		for _, match := range byFile[file] {
+			rows = append(rows, []any{match.Name, match.LineNumber, match.IndentLevel})
+		}
+		if err := writeSheetRows(book, sheet, rows); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating workbook %s: %v", path, err)
+	}
+
+	if err := book.Write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing workbook %s: %v", path, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing workbook %s: %v", path, err)
+	}
+
+	return nil
+}
+
+func writeSheetRows(book *excelize.File, sheet string, rows [][]any) error {
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return fmt.Errorf("error addressing row %d of sheet %s: %v", i+1, sheet, err)
+		}
+		if err := book.SetSheetRow(sheet, cell, &row); err != nil {
+			return fmt.Errorf("error writing row %d of sheet %s: %v", i+1, sheet, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !xlsx
+
+package justbe
+
+import "fmt"
+
+func writeXLSX(path string, paths []string, matches []MatchedLine) error {
+	return fmt.Errorf("xlsx export is not available, rebuild with -tags xlsx")
+}